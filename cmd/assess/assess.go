@@ -0,0 +1,243 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package assess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/output"
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// The `--format` report formats
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+// The severities of findings, most severe first
+const (
+	severityHigh   = "high"
+	severityMedium = "medium"
+	severityLow    = "low"
+	severityInfo   = "info"
+)
+
+var severities = []string{severityHigh, severityMedium, severityLow, severityInfo}
+
+// The titles of the severities in the Markdown report
+var severityTitles = map[string]string{
+	severityHigh:   "High",
+	severityMedium: "Medium",
+	severityLow:    "Low",
+	severityInfo:   "Info",
+}
+
+// `tfm assess` command
+var (
+	o                output.Output
+	format           string
+	outputFile       string
+	largeStateSize   int64
+	longStateHistory int
+	AssessCmd        = &cobra.Command{
+		Use:   "assess",
+		Short: "Assess migration readiness",
+		Long:  "Scans the source org and reports what needs to be done before its workspaces can be migrated",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatMarkdown && format != formatJSON {
+				return fmt.Errorf("invalid --format %q, expected markdown or json", format)
+			}
+			// Progress messages would mix into a report written to stdout
+			o.JsonOutput = outputFile == ""
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return assess(tfclient.GetClientContexts())
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	AssessCmd.Flags().StringVarP(&format, "format", "", formatMarkdown, "Report format: markdown or json")
+	AssessCmd.Flags().StringVarP(&outputFile, "output", "", "", "Write the report to a file instead of stdout")
+	AssessCmd.Flags().Int64VarP(&largeStateSize, "large-state-size", "", 50, "Current states larger than this size in MiB are reported")
+	AssessCmd.Flags().IntVarP(&longStateHistory, "long-state-history", "", 1000, "Workspaces with more state versions than this are reported")
+}
+
+// A finding of a check. Workspace is empty for findings about the organization.
+type finding struct {
+	Check       string `json:"check"`
+	Severity    string `json:"severity"`
+	Workspace   string `json:"workspace,omitempty"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation"`
+}
+
+// The organization of a side
+type reportSide struct {
+	Hostname     string `json:"hostname"`
+	Organization string `json:"organization"`
+}
+
+// The readiness report of the source organization
+type report struct {
+	Source      reportSide     `json:"source"`
+	Destination reportSide     `json:"destination"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Workspaces  int            `json:"workspaces"`
+	Summary     map[string]int `json:"summary"`
+	Findings    []finding      `json:"findings"`
+}
+
+// Adds a finding to the report
+func (r *report) add(check string, severity string, workspace string, message string, remediation string) {
+	r.Findings = append(r.Findings, finding{
+		Check:       check,
+		Severity:    severity,
+		Workspace:   workspace,
+		Message:     message,
+		Remediation: remediation,
+	})
+	r.Summary[severity]++
+}
+
+// Main function for `tfm assess`
+func assess(c tfclient.ClientContexts) error {
+	o.AddMessageUserProvided("Assessing the migration readiness of", c.SourceHostname+"/"+c.SourceOrganizationName)
+
+	workspaces, err := helper.GetWorkspacesCfg(c.SourceClient, c.SourceContext, c.SourceOrganizationName, "source")
+	if err != nil {
+		return err
+	}
+
+	o.AddFormattedMessageCalculated("Found %d Workspaces", len(workspaces))
+
+	r := &report{
+		Source:      reportSide{Hostname: c.SourceHostname, Organization: c.SourceOrganizationName},
+		Destination: reportSide{Hostname: c.DestinationHostname, Organization: c.DestinationOrganizationName},
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Workspaces:  len(workspaces),
+		Summary:     map[string]int{severityHigh: 0, severityMedium: 0, severityLow: 0, severityInfo: 0},
+		Findings:    []finding{},
+	}
+
+	a, err := newAssessment(c, workspaces)
+	if err != nil {
+		return err
+	}
+
+	for _, ws := range workspaces {
+		o.AddMessageUserProvided("Assessing workspace", ws.Name)
+		if err := a.checkWorkspace(r, ws); err != nil {
+			return errors.Wrap(err, "Failed to assess workspace "+ws.Name)
+		}
+	}
+
+	if err := a.checkOrganization(r); err != nil {
+		return errors.Wrap(err, "Failed to assess organization "+c.SourceOrganizationName)
+	}
+
+	sortFindings(r.Findings)
+
+	var data []byte
+	if format == formatJSON {
+		data, err = json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "Failed to marshal the report to JSON")
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(markdownReport(r))
+	}
+
+	if outputFile == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(outputFile, data, 0644); err != nil {
+		return errors.Wrap(err, "Failed to write "+outputFile)
+	}
+
+	for _, severity := range severities {
+		o.AddDeferredMessageRead(severityTitles[severity]+" findings", r.Summary[severity])
+	}
+	o.AddDeferredMessageRead("Report written to", outputFile)
+
+	return nil
+}
+
+// Sorts findings by severity, keeping the order of the checks
+func sortFindings(findings []finding) {
+	rank := map[string]int{}
+	for i, s := range severities {
+		rank[s] = i
+	}
+
+	sorted := make([]finding, 0, len(findings))
+	for i := range severities {
+		for _, f := range findings {
+			if rank[f.Severity] == i {
+				sorted = append(sorted, f)
+			}
+		}
+	}
+	copy(findings, sorted)
+}
+
+// Escapes a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// Renders the report as Markdown with a section per severity
+func markdownReport(r *report) string {
+	var b strings.Builder
+
+	b.WriteString("# Migration Readiness Assessment\n\n")
+	fmt.Fprintf(&b, "- Source: `%v/%v`\n", r.Source.Hostname, r.Source.Organization)
+	fmt.Fprintf(&b, "- Destination: `%v/%v`\n", r.Destination.Hostname, r.Destination.Organization)
+	fmt.Fprintf(&b, "- Workspaces assessed: %d\n", r.Workspaces)
+	fmt.Fprintf(&b, "- Generated at: %v\n\n", r.GeneratedAt.Format(time.RFC3339))
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Severity | Findings |\n| --- | --- |\n")
+	for _, severity := range severities {
+		fmt.Fprintf(&b, "| %v | %d |\n", severityTitles[severity], r.Summary[severity])
+	}
+
+	if len(r.Findings) == 0 {
+		b.WriteString("\nNo findings, the workspaces are ready to be migrated.\n")
+		return b.String()
+	}
+
+	for _, severity := range severities {
+		if r.Summary[severity] == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %v\n\n", severityTitles[severity])
+		b.WriteString("| Check | Workspace | Finding | Remediation |\n| --- | --- | --- | --- |\n")
+		for _, f := range r.Findings {
+			if f.Severity != severity {
+				continue
+			}
+			fmt.Fprintf(&b, "| %v | %v | %v | %v |\n", f.Check, markdownCell(f.Workspace), markdownCell(f.Message), markdownCell(f.Remediation))
+		}
+	}
+
+	return b.String()
+}
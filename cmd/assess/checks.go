@@ -0,0 +1,338 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package assess
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// All functions related to the checks of `tfm assess`
+
+// Matches exact Terraform versions, constraints such as `~> 1.5` can not be checked
+var exactVersion = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+
+// The config file maps and destination details the source workspaces are checked against
+type assessment struct {
+	c                 tfclient.ClientContexts
+	selected          map[string]bool
+	vcsMap            map[string]string
+	agentsMap         map[string]string
+	agentAssignmentID string
+	// The Terraform versions of the destination, nil if they could not be listed
+	destVersions map[string]bool
+	versionsErr  error
+}
+
+// Reads the config file maps and the Terraform versions of the destination
+func newAssessment(c tfclient.ClientContexts, workspaces []*tfe.Workspace) (*assessment, error) {
+	a := &assessment{
+		c:                 c,
+		selected:          map[string]bool{},
+		agentAssignmentID: viper.GetString("agent-assignment-id"),
+	}
+
+	for _, ws := range workspaces {
+		a.selected[ws.Name] = true
+	}
+
+	var err error
+	a.vcsMap, err = helper.ViperStringSliceMap("vcs-map")
+	if err != nil {
+		return nil, errors.New("Invalid input for vcs-map")
+	}
+
+	a.agentsMap, err = helper.ViperStringSliceMap("agents-map")
+	if err != nil {
+		return nil, errors.New("Invalid input for agents-map")
+	}
+
+	a.destVersions, a.versionsErr = getDestinationVersions(c)
+
+	return a, nil
+}
+
+// Lists the enabled Terraform versions of the destination. Requires a site admin token on TFE,
+// the API is not available in HCP Terraform.
+func getDestinationVersions(c tfclient.ClientContexts) (map[string]bool, error) {
+	versions := map[string]bool{}
+
+	opts := tfe.AdminTerraformVersionsListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		items, err := c.DestinationClient.Admin.TerraformVersions.List(c.DestinationContext, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range items.Items {
+			if v.Enabled {
+				versions[v.Version] = true
+			}
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return versions, nil
+}
+
+// Runs the checks of a source workspace
+func (a *assessment) checkWorkspace(r *report, ws *tfe.Workspace) error {
+	a.checkVCS(r, ws)
+	a.checkAgentPool(r, ws)
+	a.checkTerraformVersion(r, ws)
+
+	if ws.Locked {
+		r.add("locked-workspace", severityMedium, ws.Name,
+			"The workspace is locked",
+			"Find out why the workspace is locked and unlock it before copying its state.")
+	}
+
+	if ws.GlobalRemoteState {
+		r.add("global-remote-state", severityMedium, ws.Name,
+			"The state is shared with all workspaces of the organization",
+			"Copy with `tfm copy workspaces --remote-state-sharing --consolidate-global`, or share the state with the workspaces that read it only.")
+	}
+
+	if err := a.checkRuns(r, ws); err != nil {
+		return err
+	}
+	if err := a.checkVariables(r, ws); err != nil {
+		return err
+	}
+	if err := a.checkStates(r, ws); err != nil {
+		return err
+	}
+	return a.checkRunTriggers(r, ws)
+}
+
+// Reports a VCS connection with no vcs-map entry
+func (a *assessment) checkVCS(r *report, ws *tfe.Workspace) {
+	if ws.VCSRepo == nil {
+		return
+	}
+
+	vcsID := ws.VCSRepo.OAuthTokenID
+	if vcsID == "" {
+		vcsID = ws.VCSRepo.GHAInstallationID
+	}
+
+	if _, ok := a.vcsMap[vcsID]; ok {
+		return
+	}
+
+	r.add("vcs-mapping", severityHigh, ws.Name,
+		fmt.Sprintf("Connected to %v with VCS provider %v, which has no vcs-map entry", ws.VCSRepo.Identifier, vcsID),
+		fmt.Sprintf("Create the VCS provider in the destination and add `%v=<destination ID>` to vcs-map.", vcsID))
+}
+
+// Reports an agent pool with no agents-map entry
+func (a *assessment) checkAgentPool(r *report, ws *tfe.Workspace) {
+	if ws.ExecutionMode != "agent" || ws.AgentPool == nil || a.agentAssignmentID != "" {
+		return
+	}
+
+	if _, ok := a.agentsMap[ws.AgentPool.ID]; ok {
+		return
+	}
+
+	r.add("agent-pool-mapping", severityHigh, ws.Name,
+		fmt.Sprintf("Runs on agent pool %v, which has no agents-map entry", ws.AgentPool.ID),
+		fmt.Sprintf("Create the agent pool with `tfm copy agent-pools`, or add `%v=<destination ID>` to agents-map.", ws.AgentPool.ID))
+}
+
+// Reports a Terraform version that is not available in the destination
+func (a *assessment) checkTerraformVersion(r *report, ws *tfe.Workspace) {
+	if a.destVersions == nil || !exactVersion.MatchString(ws.TerraformVersion) || a.destVersions[ws.TerraformVersion] {
+		return
+	}
+
+	r.add("terraform-version", severityHigh, ws.Name,
+		fmt.Sprintf("Uses Terraform %v, which is not available in the destination", ws.TerraformVersion),
+		fmt.Sprintf("Add Terraform %v to the destination, or upgrade the workspace to an available version before the migration.", ws.TerraformVersion))
+}
+
+// Reports runs that have not finished
+func (a *assessment) checkRuns(r *report, ws *tfe.Workspace) error {
+	runs, err := helper.ActiveRuns(a.c.SourceContext, a.c.SourceClient, ws.ID, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to list runs")
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+
+	r.add("active-runs", severityMedium, ws.Name,
+		fmt.Sprintf("Has %d active runs: %v", len(runs), helper.FormatRuns(runs)),
+		"Wait for the runs to finish, or copy the state with `--on-active-run wait`.")
+	return nil
+}
+
+// Reports sensitive variables, their values can not be read and copied
+func (a *assessment) checkVariables(r *report, ws *tfe.Workspace) error {
+	sensitive := 0
+
+	opts := tfe.VariableListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		items, err := a.c.SourceClient.Variables.List(a.c.SourceContext, ws.ID, &opts)
+		if err != nil {
+			return errors.Wrap(err, "failed to list variables")
+		}
+
+		for _, v := range items.Items {
+			if v.Sensitive {
+				sensitive++
+			}
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	if sensitive == 0 {
+		return nil
+	}
+
+	r.add("sensitive-variables", severityMedium, ws.Name,
+		fmt.Sprintf("Has %d sensitive variables, their values can not be read", sensitive),
+		"Set the values in the destination after the migration, or copy the variables with `--skip-sensitive-vars` and set them separately.")
+	return nil
+}
+
+// Reports very large current states and very long state histories
+func (a *assessment) checkStates(r *report, ws *tfe.Workspace) error {
+	opts := tfe.StateVersionListOptions{
+		ListOptions:  tfe.ListOptions{PageNumber: 1, PageSize: 1},
+		Organization: a.c.SourceOrganizationName,
+		Workspace:    ws.Name,
+	}
+	items, err := a.c.SourceClient.StateVersions.List(a.c.SourceContext, &opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to list state versions")
+	}
+
+	versions := len(items.Items)
+	if items.Pagination != nil {
+		versions = items.TotalCount
+	}
+
+	if versions > longStateHistory {
+		r.add("long-state-history", severityLow, ws.Name,
+			fmt.Sprintf("Has %d state versions", versions),
+			"Copy only the latest states with `--last`, or the current state with `--only-current`, to shorten the migration.")
+	}
+
+	if versions == 0 {
+		return nil
+	}
+
+	current, err := a.c.SourceClient.StateVersions.ReadCurrent(a.c.SourceContext, ws.ID)
+	if err == tfe.ErrResourceNotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read the current state")
+	}
+
+	// The state version does not report its size, the state is streamed to count its bytes
+	size, err := tfstate.DownloadSize(a.c.SourceContext, a.c.SourceClient, current.DownloadURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to download the current state")
+	}
+
+	if size > largeStateSize*1024*1024 {
+		r.add("large-state", severityMedium, ws.Name,
+			fmt.Sprintf("The current state is %.1f MiB", float64(size)/1024/1024),
+			"Plan extra time to copy the states, and consider splitting the workspace.")
+	}
+
+	return nil
+}
+
+// Reports run triggers with workspaces that are not selected for the migration
+func (a *assessment) checkRunTriggers(r *report, ws *tfe.Workspace) error {
+	for _, triggerType := range []tfe.RunTriggerFilterOp{tfe.RunTriggerInbound, tfe.RunTriggerOutbound} {
+		opts := tfe.RunTriggerListOptions{
+			ListOptions:    tfe.ListOptions{PageNumber: 1, PageSize: 100},
+			RunTriggerType: triggerType,
+		}
+		for {
+			items, err := a.c.SourceClient.RunTriggers.List(a.c.SourceContext, ws.ID, &opts)
+			if err != nil {
+				return errors.Wrap(err, "failed to list run triggers")
+			}
+
+			for _, t := range items.Items {
+				if triggerType == tfe.RunTriggerInbound && !a.selected[t.SourceableName] {
+					r.add("run-trigger", severityMedium, ws.Name,
+						fmt.Sprintf("Runs are triggered by workspace %v, which is not selected", t.SourceableName),
+						"Migrate the connected workspaces together, or recreate the run trigger after the migration.")
+				}
+				if triggerType == tfe.RunTriggerOutbound && !a.selected[t.WorkspaceName] {
+					r.add("run-trigger", severityMedium, ws.Name,
+						fmt.Sprintf("Triggers runs in workspace %v, which is not selected", t.WorkspaceName),
+						"Migrate the connected workspaces together, or recreate the run trigger after the migration.")
+				}
+			}
+
+			if items.CurrentPage >= items.TotalPages {
+				break
+			}
+			opts.PageNumber = items.NextPage
+		}
+	}
+
+	return nil
+}
+
+// Runs the checks of the source organization
+func (a *assessment) checkOrganization(r *report) error {
+	if a.versionsErr != nil {
+		r.add("terraform-versions", severityInfo, "",
+			fmt.Sprintf("The Terraform versions of the destination could not be listed: %v", a.versionsErr),
+			"Use a site admin token for a TFE destination to check the Terraform versions of the workspaces. HCP Terraform supports all released versions.")
+	}
+
+	opts := tfe.PolicySetListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		items, err := a.c.SourceClient.PolicySets.List(a.c.SourceContext, a.c.SourceOrganizationName, &opts)
+		if err != nil {
+			r.add("policy-set", severityInfo, "",
+				fmt.Sprintf("The policy sets could not be listed: %v", err),
+				"Check the policy sets of the organization in the UI.")
+			return nil
+		}
+
+		for _, ps := range items.Items {
+			r.add("policy-set", severityMedium, "",
+				fmt.Sprintf("%v policy set %v with %d policies is not migrated", ps.Kind, ps.Name, ps.PolicyCount),
+				"Recreate the policy set in the destination, or connect it to its VCS repository, and attach it to the migrated workspaces or projects.")
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return nil
+}
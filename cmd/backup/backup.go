@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp-services/tfm/output"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/spf13/cobra"
+)
+
+// `tfm backup` and `tfm restore` commands
+var (
+	o    output.Output
+	side string
+	dir  string
+
+	BackupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Backup",
+		Long:  "Backs up objects in source or destination",
+	}
+
+	RestoreCmd = &cobra.Command{
+		Use:   "restore",
+		Short: "Restore",
+		Long:  "Restores objects backed up with tfm backup in source or destination",
+	}
+)
+
+func init() {
+	BackupCmd.PersistentFlags().StringVar(&side, "side", "", "Specify source or destination side to process")
+	RestoreCmd.PersistentFlags().StringVar(&side, "side", "", "Specify source or destination side to process. Defaults to the side in the backup manifest")
+}
+
+// One side of the migration
+type sideContext struct {
+	name         string
+	hostname     string
+	organization string
+	client       *tfe.Client
+	ctx          context.Context
+}
+
+// Returns the client of the source or destination side
+func getSide(c tfclient.ClientContexts, name string) (sideContext, error) {
+	switch name {
+	case "", "source":
+		return sideContext{name: "source", hostname: c.SourceHostname, organization: c.SourceOrganizationName, client: c.SourceClient, ctx: c.SourceContext}, nil
+	case "destination":
+		return sideContext{name: "destination", hostname: c.DestinationHostname, organization: c.DestinationOrganizationName, client: c.DestinationClient, ctx: c.DestinationContext}, nil
+	}
+	return sideContext{}, fmt.Errorf("invalid --side %q, expected source or destination", name)
+}
+
+// Asks the user to confirm an operation unless --autoapprove is set
+func confirm(cmd *cobra.Command) bool {
+
+	var input string
+
+	fmt.Printf("Do you want to continue with this operation? [y|n]: ")
+
+	auto, err := cmd.Flags().GetBool("autoapprove")
+
+	if err != nil {
+		fmt.Println("Error Retrieving autoapprove flag value: ", err)
+	}
+
+	// Check if --autoapprove=false
+	if !auto {
+		_, err := fmt.Scanln(&input)
+		if err != nil {
+			return false
+		}
+	} else {
+		input = "y"
+		fmt.Println("y(autoapprove=true)")
+	}
+
+	input = strings.ToLower(input)
+
+	if input == "y" || input == "yes" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// `tfm restore states` command
+	statesRestoreCmd = &cobra.Command{
+		Use:   "states",
+		Short: "States command",
+		Long:  "Restore the states of a backup written by tfm backup states",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return restoreStates(cmd, tfclient.GetClientContexts(), dir)
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	statesRestoreCmd.Flags().StringVarP(&dir, "dir", "", "", "The backup directory containing the manifest.json to restore")
+	statesRestoreCmd.MarkFlagRequired("dir")
+
+	// Add commands
+	RestoreCmd.AddCommand(statesRestoreCmd)
+}
+
+// Returns the state to restore for each workspace in a manifest, the state that was
+// current when it was backed up or otherwise the one with the highest serial
+func getRestoreEntries(m *tfstate.Manifest) []tfstate.ManifestEntry {
+	entries := []tfstate.ManifestEntry{}
+	index := map[string]int{}
+
+	for _, e := range m.States {
+		i, ok := index[e.Workspace]
+		if !ok {
+			index[e.Workspace] = len(entries)
+			entries = append(entries, e)
+			continue
+		}
+		if entries[i].Current {
+			continue
+		}
+		if e.Current || e.Serial > entries[i].Serial {
+			entries[i] = e
+		}
+	}
+
+	return entries
+}
+
+// Restores the state of a manifest entry as a new state version of the workspace. A state with
+// a serial lower than or equal to the current state gets the next serial.
+func restoreState(s sideContext, backupDir string, e tfstate.ManifestEntry) (string, error) {
+	ws, err := s.client.Workspaces.Read(s.ctx, s.organization, e.Workspace)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read workspace")
+	}
+
+	state, err := os.ReadFile(filepath.Join(backupDir, e.File))
+	if err != nil {
+		return "", err
+	}
+	if md5 := tfstate.MD5(state); md5 != e.MD5 {
+		return "", fmt.Errorf("backup file %v MD5 %v does not match the manifest MD5 %v", e.File, md5, e.MD5)
+	}
+
+	parsed, err := tfstate.Parse(state)
+	if err != nil {
+		return "", err
+	}
+
+	force := false
+	current, err := s.client.StateVersions.ReadCurrent(s.ctx, ws.ID)
+	if err != nil && err != tfe.ErrResourceNotFound {
+		return "", errors.Wrap(err, "failed to read the current state")
+	}
+
+	if current != nil {
+		// The current state is downloaded once for its MD5 checksum, serial and lineage
+		currentState, err := tfstate.Download(s.ctx, s.client, current.DownloadURL)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to download the current state")
+		}
+		defer currentState.Remove()
+
+		if currentState.MD5 == e.MD5 {
+			return "the backed up state is the current state", nil
+		}
+
+		currentParsed, err := parseFile(currentState)
+		if err != nil {
+			return "", err
+		}
+
+		if parsed.Serial <= currentParsed.Serial {
+			result, err := tfstate.WithSerial(state, currentParsed.Serial+1)
+			if err != nil {
+				return "", err
+			}
+			fmt.Printf("Restoring state of workspace %v with a new serial:\n%v", ws.Name, result.Diff())
+			state, parsed = result.State, result.Parsed
+		}
+
+		// A state with another lineage can only replace the current state with force
+		force = parsed.Lineage != currentParsed.Lineage
+	}
+
+	f, err := tfstate.NewFile(state)
+	if err != nil {
+		return "", err
+	}
+	defer f.Remove()
+
+	if !ws.Locked {
+		message := "Restoring state"
+		if _, err := s.client.Workspaces.Lock(s.ctx, ws.ID, tfe.WorkspaceLockOptions{Reason: &message}); err != nil {
+			return "", errors.Wrap(err, "failed to lock workspace")
+		}
+		defer s.client.Workspaces.Unlock(s.ctx, ws.ID)
+	}
+
+	jsonOutputs, err := tfstate.JSONOutputs(parsed)
+	if err != nil {
+		jsonOutputs = nil
+	}
+
+	sv, _, err := tfstate.Upload(s.ctx, s.client, ws.ID, tfstate.UploadOptions{
+		State:       f,
+		Parsed:      parsed,
+		JSONOutputs: jsonOutputs,
+		Force:       force,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to upload state")
+	}
+
+	if err := tfstate.Verify(s.ctx, s.client, sv.ID, f.MD5); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("restored as state version %v serial %v", sv.ID, parsed.Serial), nil
+}
+
+// Parses a downloaded state file
+func parseFile(f *tfstate.File) (*tfstate.State, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return tfstate.ParseReader(file)
+}
+
+// Main function for `tfm restore states`
+func restoreStates(cmd *cobra.Command, c tfclient.ClientContexts, backupDir string) error {
+	m, err := tfstate.ReadManifest(backupDir)
+	if err != nil {
+		return err
+	}
+
+	restoreSide := m.Side
+	if cmd.Flags().Lookup("side").Changed {
+		restoreSide = side
+	}
+
+	s, err := getSide(c, restoreSide)
+	if err != nil {
+		return err
+	}
+
+	if s.hostname != m.Hostname || s.organization != m.Organization {
+		o.AddMessageUserProvided3("Backup was taken from", m.Hostname+"/"+m.Organization, "and will be restored to", s.hostname+"/"+s.organization)
+	}
+
+	entries := getRestoreEntries(m)
+
+	fmt.Printf("The following states will be restored to %v/%v:\n", s.hostname, s.organization)
+	for _, e := range entries {
+		fmt.Printf("  %v serial %v (backed up from state version %v created %v)\n", e.Workspace, e.Serial, e.StateVersionID, e.CreatedAt)
+	}
+
+	if !confirm(cmd) {
+		fmt.Println("\n\n**** Canceling tfm run **** ")
+		return nil
+	}
+
+	o.AddTableHeaders("Workspace", "Backup Serial", "Result")
+
+	var failed []interface{}
+	for _, e := range entries {
+		result, err := restoreState(s, backupDir, e)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%v: %v", e.Workspace, err))
+			result = "failed"
+		}
+		o.AddTableRows(e.Workspace, e.Serial, result)
+	}
+
+	if len(failed) > 0 {
+		o.AddDeferredListMessageRead("Failed state restores", failed)
+	}
+
+	return nil
+}
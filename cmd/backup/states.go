@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	allStates bool
+
+	// `tfm backup states` command
+	statesBackupCmd = &cobra.Command{
+		Use:   "states",
+		Short: "States command",
+		Long:  "Back up the current state, or all state versions, of workspaces to a local directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return backupStates(tfclient.GetClientContexts(), allStates)
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	statesBackupCmd.Flags().BoolVarP(&allStates, "all", "", false, "Back up all state versions instead of only the current state")
+	statesBackupCmd.Flags().StringVarP(&dir, "dir", "", "", "The directory to write the backup in. Defaults to state-backup-dir in the config file or "+tfstate.DefaultBackupDir)
+
+	// Add commands
+	BackupCmd.AddCommand(statesBackupCmd)
+}
+
+// Lists all state versions of a workspace, newest first
+func discoverStates(s sideContext, ws *tfe.Workspace) ([]*tfe.StateVersion, error) {
+	states := []*tfe.StateVersion{}
+
+	opts := tfe.StateVersionListOptions{
+		ListOptions:  tfe.ListOptions{PageNumber: 1, PageSize: 100},
+		Organization: s.organization,
+		Workspace:    ws.Name,
+	}
+	for {
+		items, err := s.client.StateVersions.List(s.ctx, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		states = append(states, items.Items...)
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return states, nil
+}
+
+// Main function for `tfm backup states`
+func backupStates(c tfclient.ClientContexts, all bool) error {
+	s, err := getSide(c, side)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := helper.GetWorkspacesCfg(s.client, s.ctx, s.organization, s.name)
+	if err != nil {
+		return err
+	}
+
+	o.AddMessageUserProvided2(len(workspaces), "workspaces will be backed up from", s.hostname)
+
+	b, err := tfstate.NewBackup(tfstate.BackupDir(dir), s.name, s.hostname, s.organization)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create the backup directory")
+	}
+
+	o.AddTableHeaders("Workspace", "Serial", "Lineage", "MD5", "Created At", "Current", "File")
+
+	for _, ws := range workspaces {
+		current, err := s.client.StateVersions.ReadCurrent(s.ctx, ws.ID)
+		if err == tfe.ErrResourceNotFound {
+			o.AddMessageUserProvided("Workspace has no state to back up:", ws.Name)
+			continue
+		}
+		if err != nil {
+			o.Close()
+			return errors.Wrap(err, "Failed to read the current state of workspace "+ws.Name)
+		}
+
+		states := []*tfe.StateVersion{current}
+		if all {
+			states, err = discoverStates(s, ws)
+			if err != nil {
+				o.Close()
+				return errors.Wrap(err, "Failed to list the states of workspace "+ws.Name)
+			}
+		}
+
+		for _, sv := range states {
+			entry, err := b.Add(s.ctx, s.client, ws, sv, sv.ID == current.ID)
+			if err != nil {
+				o.Close()
+				return errors.Wrapf(err, "Failed to back up state version %v of workspace %v", sv.ID, ws.Name)
+			}
+			o.AddTableRows(entry.Workspace, entry.Serial, entry.Lineage, entry.MD5, entry.CreatedAt.Format(time.RFC3339), entry.Current, entry.File)
+		}
+	}
+
+	o.AddDeferredMessageRead("Backup written to", b.Dir)
+	fmt.Printf("Backed up %d state versions to %v\n", len(b.Manifest.States), b.Dir)
+
+	return nil
+}
@@ -0,0 +1,429 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	createAgentTokens bool
+	agentTokensFile   string
+
+	// `tfm copy agent-pools` command
+	agentPoolsCopyCmd = &cobra.Command{
+		Use:     "agent-pools",
+		Aliases: []string{"apools"},
+		Short:   "Copy Agent Pools",
+		Long:    "Copy Agent Pools from source to destination org and write the resulting agents-map to the config file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return copyAgentPools(
+				tfclient.GetClientContexts(), createAgentTokens, agentTokensFile)
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+// Agent pool allowed projects. go-tfe does not expose them yet, so the agent pool is read
+// and updated with this minimal JSON:API model.
+type agentPoolAllowedProjects struct {
+	ID              string         `jsonapi:"primary,agent-pools"`
+	AllowedProjects []*tfe.Project `jsonapi:"relation,allowed-projects,omitempty"`
+}
+
+func init() {
+	agentPoolsCopyCmd.Flags().BoolVarP(&createAgentTokens, "create-tokens", "", false, "Create a new agent token for each destination agent pool")
+	agentPoolsCopyCmd.Flags().StringVarP(&agentTokensFile, "tokens-file", "", "agent-tokens.json", "Local file the created agent tokens are written to. Must be used with --create-tokens flag")
+
+	// Add commands
+	CopyCmd.AddCommand(agentPoolsCopyCmd)
+}
+
+// Get all source target Agent Pools
+func discoverSrcAgentPools(c tfclient.ClientContexts) ([]*tfe.AgentPool, error) {
+	o.AddMessageUserProvided("Getting list of Agent Pools from: ", c.SourceHostname)
+	srcPools := []*tfe.AgentPool{}
+
+	opts := tfe.AgentPoolListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100},
+	}
+	for {
+		items, err := c.SourceClient.AgentPools.List(c.SourceContext, c.SourceOrganizationName, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		srcPools = append(srcPools, items.Items...)
+
+		o.AddFormattedMessageCalculated("Found %d Agent Pools", len(srcPools))
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+
+	}
+
+	return srcPools, nil
+}
+
+// Get all destination target Agent Pools
+func discoverDestAgentPools(c tfclient.ClientContexts) ([]*tfe.AgentPool, error) {
+	o.AddMessageUserProvided("Getting list of Agent Pools from: ", c.DestinationHostname)
+	destPools := []*tfe.AgentPool{}
+
+	opts := tfe.AgentPoolListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100},
+	}
+	for {
+		items, err := c.DestinationClient.AgentPools.List(c.DestinationContext, c.DestinationOrganizationName, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		destPools = append(destPools, items.Items...)
+
+		o.AddFormattedMessageCalculated("Found %d Agent Pools", len(destPools))
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+
+	}
+
+	return destPools, nil
+}
+
+// Takes an agent pool name and a slice of agent pools and returns the matching
+// agent pool if the name exists within the provided slice.
+func getAgentPoolByName(poolName string, pools []*tfe.AgentPool) (*tfe.AgentPool, bool) {
+	for _, p := range pools {
+		if poolName == p.Name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Translates the allowed workspaces of a source agent pool into destination workspaces.
+// Workspaces are matched by name, honoring the `workspaces-map` if one is configured.
+func getDestAllowedWorkspaces(srcPool *tfe.AgentPool, srcWorkspaces []*tfe.Workspace, destWorkspaces []*tfe.Workspace, wsMapCfg map[string]string) []*tfe.Workspace {
+	allowed := []*tfe.Workspace{}
+
+	for _, a := range srcPool.AllowedWorkspaces {
+		srcWorkspaceName := ""
+		for _, ws := range srcWorkspaces {
+			if ws.ID == a.ID {
+				srcWorkspaceName = ws.Name
+				break
+			}
+		}
+
+		if srcWorkspaceName == "" {
+			o.AddFormattedMessageUserProvided2("Allowed Workspace ID %v of Agent Pool %v not found in source. Skipping.", a.ID, srcPool.Name)
+			continue
+		}
+
+		destWorkSpaceName := srcWorkspaceName
+		if len(wsMapCfg) > 0 {
+			if name, ok := wsMapCfg[srcWorkspaceName]; ok {
+				destWorkSpaceName = name
+			}
+		}
+
+		found := false
+		for _, ws := range destWorkspaces {
+			if ws.Name == destWorkSpaceName {
+				allowed = append(allowed, &tfe.Workspace{ID: ws.ID})
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			o.AddFormattedMessageUserProvided2("Allowed Workspace %v of Agent Pool %v does not exist in destination. Skipping.", destWorkSpaceName, srcPool.Name)
+		}
+	}
+
+	return allowed
+}
+
+// Read the allowed projects of a source agent pool. Releases without allowed projects return
+// none of them.
+func discoverSrcAgentPoolAllowedProjects(c tfclient.ClientContexts, srcPoolID string) ([]*tfe.Project, error) {
+	req, err := c.SourceClient.NewRequest("GET", fmt.Sprintf("agent-pools/%s", url.PathEscape(srcPoolID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &agentPoolAllowedProjects{}
+	err = req.Do(c.SourceContext, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	return pool.AllowedProjects, nil
+}
+
+// Translates the allowed projects of a source agent pool into destination projects. Projects are
+// matched by name, honoring the `projects-map` if one is configured. Returns the names of the
+// source projects that have no destination project.
+func getDestAllowedProjects(srcPool *tfe.AgentPool, allowed []*tfe.Project, srcProjects []*tfe.Project, destProjects []*tfe.Project, projMapCfg map[string]string) ([]*tfe.Project, []string) {
+	destAllowed := []*tfe.Project{}
+	missing := []string{}
+
+	for _, a := range allowed {
+		srcProjectName := ""
+		for _, p := range srcProjects {
+			if p.ID == a.ID {
+				srcProjectName = p.Name
+				break
+			}
+		}
+
+		if srcProjectName == "" {
+			o.AddFormattedMessageUserProvided2("Allowed Project ID %v of Agent Pool %v not found in source. Skipping.", a.ID, srcPool.Name)
+			missing = append(missing, a.ID)
+			continue
+		}
+
+		destProjectName := srcProjectName
+		if name, ok := projMapCfg[srcProjectName]; ok {
+			destProjectName = name
+		}
+
+		found := false
+		for _, p := range destProjects {
+			if p.Name == destProjectName {
+				destAllowed = append(destAllowed, &tfe.Project{ID: p.ID})
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			o.AddFormattedMessageUserProvided2("Allowed Project %v of Agent Pool %v does not exist in destination. Skipping.", destProjectName, srcPool.Name)
+			missing = append(missing, srcProjectName)
+		}
+	}
+
+	return destAllowed, missing
+}
+
+// Sets the allowed projects of a destination agent pool
+func updateDestAgentPoolAllowedProjects(c tfclient.ClientContexts, destPool *tfe.AgentPool, allowed []*tfe.Project) error {
+	opts := &agentPoolAllowedProjects{
+		ID:              destPool.ID,
+		AllowedProjects: allowed,
+	}
+
+	req, err := c.DestinationClient.NewRequest("PATCH", fmt.Sprintf("agent-pools/%s", url.PathEscape(destPool.ID)), opts)
+	if err != nil {
+		return err
+	}
+
+	return req.Do(c.DestinationContext, nil)
+}
+
+// Creates a new agent token for a destination agent pool
+func createAgentPoolToken(c tfclient.ClientContexts, destPool *tfe.AgentPool) (string, error) {
+	description := "tfm migration"
+
+	token, err := c.DestinationClient.AgentTokens.Create(c.DestinationContext, destPool.ID, tfe.AgentTokenCreateOptions{
+		Description: &description,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token.Token, nil
+}
+
+// Writes the created agent tokens, keyed by destination agent pool name, to a local file
+// that only the current user can read.
+func writeAgentTokensFile(tokensFile string, tokens map[string]string) error {
+	content, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(tokensFile, content, 0600)
+}
+
+// Main function for `tfm copy agent-pools`
+// Creates same named agent pools in the destination and writes the resulting
+// source=destination agent pool IDs to the `agents-map` in the config file.
+// Results are saved on return, including when a pool fails partway through, so a
+// rerun reuses the pools already created instead of losing their IDs and tokens.
+func copyAgentPools(c tfclient.ClientContexts, createTokens bool, tokensFile string) (err error) {
+
+	// Get the source agent pools
+	srcPools, err := discoverSrcAgentPools(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Agent Pools from source")
+	}
+
+	// Get the destination agent pools
+	destPools, err := discoverDestAgentPools(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Agent Pools from destination")
+	}
+
+	// Workspaces are needed to translate the allowed workspaces of non organization scoped pools
+	srcWorkspaces, err := discoverSrcWorkspaces(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Workspaces from source")
+	}
+
+	destWorkspaces, err := discoverDestWorkspaces(c, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Workspaces from destination")
+	}
+
+	// Get/Check if Workspace map exists
+	wsMapCfg, err := helper.ViperStringSliceMap("workspaces-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for workspaces-map")
+	}
+
+	// Projects are needed to translate the allowed projects of non organization scoped pools
+	srcProjects, err := listSrcProjects(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Projects from source")
+	}
+
+	destProjects, err := listDestProjects(c, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Projects from destination")
+	}
+
+	projMapCfg, err := helper.ViperStringSliceMap("projects-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for projects-map")
+	}
+
+	var missingProjects []interface{}
+
+	agentsMap := make(map[string]string)
+	tokens := make(map[string]string)
+
+	defer func() {
+		if saveErr := saveAgentPoolsResults(agentsMap, tokens, createTokens, tokensFile); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}()
+
+	for _, srcpool := range srcPools {
+		destpool, exists := getAgentPoolByName(srcpool.Name, destPools)
+
+		if exists {
+			o.AddMessageUserProvided2(srcpool.Name, "exists in destination will not migrate", destpool.ID)
+		} else {
+			opts := tfe.AgentPoolCreateOptions{
+				Type:               "",
+				Name:               &srcpool.Name,
+				OrganizationScoped: &srcpool.OrganizationScoped,
+			}
+
+			if !srcpool.OrganizationScoped {
+				opts.AllowedWorkspaces = getDestAllowedWorkspaces(srcpool, srcWorkspaces, destWorkspaces, wsMapCfg)
+			}
+
+			o.AddMessageUserProvided("Migrating Agent Pool: ", srcpool.Name)
+			destpool, err = c.DestinationClient.AgentPools.Create(c.DestinationContext, c.DestinationOrganizationName, opts)
+			if err != nil {
+				fmt.Println("Could not create Agent Pool.\n\n Error:", err.Error())
+				return err
+			}
+			o.AddDeferredMessageRead("Migrated", destpool.Name)
+
+			if !srcpool.OrganizationScoped {
+				allowed, err := discoverSrcAgentPoolAllowedProjects(c, srcpool.ID)
+				if err != nil {
+					o.AddErrorUserProvided2("Unable to read the allowed projects of source Agent Pool "+srcpool.Name+":", err.Error())
+				}
+
+				destAllowed, missing := getDestAllowedProjects(srcpool, allowed, srcProjects, destProjects, projMapCfg)
+				for _, p := range missing {
+					missingProjects = append(missingProjects, fmt.Sprintf("%v: %v", srcpool.Name, p))
+				}
+
+				if len(destAllowed) > 0 {
+					if err := updateDestAgentPoolAllowedProjects(c, destpool, destAllowed); err != nil {
+						return errors.Wrap(err, "failed to set the allowed projects of destination Agent Pool "+destpool.Name)
+					}
+					o.AddFormattedMessageCalculated2("Allowed %d Projects to use Agent Pool %v", len(destAllowed), destpool.Name)
+				}
+			}
+		}
+
+		agentsMap[srcpool.ID] = destpool.ID
+
+		if createTokens {
+			token, err := createAgentPoolToken(c, destpool)
+			if err != nil {
+				return errors.Wrap(err, "failed to create agent token for destination Agent Pool "+destpool.Name)
+			}
+			tokens[destpool.Name] = token
+			o.AddMessageUserProvided("Created agent token for destination Agent Pool: ", destpool.Name)
+		}
+	}
+
+	if len(missingProjects) > 0 {
+		o.AddDeferredListMessageRead("Allowed Projects of Agent Pools without a destination Project, allow them manually", missingProjects)
+	}
+
+	if len(agentsMap) == 0 {
+		o.AddMessageUserProvided("No Agent Pools found in source org: ", c.SourceOrganizationName)
+	}
+
+	return nil
+}
+
+// Writes the created agent tokens to the tokens file and the agents-map to the config file
+func saveAgentPoolsResults(agentsMap map[string]string, tokens map[string]string, createTokens bool, tokensFile string) error {
+	var tokensErr error
+	if createTokens && len(tokens) > 0 {
+		if tokensErr = writeAgentTokensFile(tokensFile, tokens); tokensErr != nil {
+			tokensErr = errors.Wrap(tokensErr, "failed to write agent tokens file")
+		} else {
+			o.AddDeferredMessageRead("Agent tokens written to", tokensFile)
+		}
+	}
+
+	if len(agentsMap) == 0 {
+		return tokensErr
+	}
+
+	// Save the agents-map so `tfm copy workspaces --agents` can run without manual ID lookups
+	if err := helper.ViperWriteStringSliceMap("agents-map", agentsMap); err != nil {
+		o.AddErrorUserProvided2("Unable to write agents-map to the config file:", err.Error())
+		o.AddMessageUserProvided("Add the following agents-map to the config file manually:", "")
+		for k, v := range agentsMap {
+			fmt.Printf("  \"%s=%s\",\n", k, v)
+		}
+		return tokensErr
+	}
+
+	o.AddDeferredMessageRead("agents-map written to", viper.ConfigFileUsed())
+
+	return tokensErr
+}
@@ -0,0 +1,455 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// All functions related to cutting workspaces over from source to destination
+
+var (
+	runTimeout time.Duration
+
+	// `tfm cutover` command
+	CutoverCmd = &cobra.Command{
+		Use:   "cutover",
+		Short: "Cut workspaces over",
+		Long: `Cuts the configured workspaces over from source to destination. For each workspace tfm locks the
+source workspace, waits for its active runs and locks the destination workspace. After a confirmation, the
+point of no return, tfm copies the states missing in the destination and verifies the current destination
+state, removes the VCS connection of the source workspace, connects the destination workspace with the
+vcs-map and unlocks it if tfm locked it. If a step fails, the steps already taken for the workspace are
+reverted. Copied states can not be reverted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cutover(cmd, tfclient.GetClientContexts())
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	CutoverCmd.Flags().DurationVarP(&runTimeout, "run-timeout", "", 30*time.Minute, "How long to wait for the active runs of a source workspace to finish")
+}
+
+// A cutover step and how to revert it. Steps without a revert function need no revert.
+type cutoverStep struct {
+	name   string
+	run    func() (string, error)
+	revert func() error
+}
+
+// The cutover of one source workspace to its destination workspace
+type workspaceCutover struct {
+	c         tfclient.ClientContexts
+	sc        *stateCopy
+	src       *tfe.Workspace
+	destName  string
+	destID    string
+	destVCSID string
+
+	// Set when tfm locked the workspace, so a revert leaves workspaces that were already locked locked
+	srcLocked  bool
+	destLocked bool
+}
+
+// The steps before the point of no return
+func (w *workspaceCutover) prepareSteps() []cutoverStep {
+	return []cutoverStep{
+		{
+			name: "Lock source workspace",
+			run: func() (string, error) {
+				return lockCutoverWorkspace(w.c.SourceContext, w.c.SourceClient, w.src.ID, &w.srcLocked)
+			},
+			revert: func() error {
+				return unlockCutoverWorkspace(w.c.SourceContext, w.c.SourceClient, w.src.ID, w.srcLocked)
+			},
+		},
+		{
+			name: "Wait for source runs",
+			run: func() (string, error) {
+				if err := helper.WaitForRuns(w.c.SourceContext, w.c.SourceClient, w.src.ID, runTimeout, false); err != nil {
+					return "", err
+				}
+				return "no active runs", nil
+			},
+		},
+		{
+			name: "Lock destination workspace",
+			run: func() (string, error) {
+				return lockCutoverWorkspace(w.c.DestinationContext, w.c.DestinationClient, w.destID, &w.destLocked)
+			},
+			revert: func() error {
+				return unlockCutoverWorkspace(w.c.DestinationContext, w.c.DestinationClient, w.destID, w.destLocked)
+			},
+		},
+	}
+}
+
+// The steps after the point of no return. State versions copied to the destination can not be
+// removed, so the state copy has no revert.
+func (w *workspaceCutover) switchSteps() []cutoverStep {
+	return []cutoverStep{
+		{
+			name: "Copy final state delta",
+			run: func() (string, error) {
+				failed := len(w.sc.failed)
+				if err := w.sc.copyWorkspace(w.c, w.src, w.destID, w.destName); err != nil {
+					return "", err
+				}
+				if len(w.sc.failed) > failed {
+					return "", fmt.Errorf("%v", w.sc.failed[len(w.sc.failed)-1])
+				}
+				return "missing states copied", nil
+			},
+		},
+		{
+			name: "Verify destination state",
+			run:  w.verifyState,
+		},
+		{
+			name: "Remove source VCS connection",
+			run: func() (string, error) {
+				if w.src.VCSRepo == nil {
+					return "skipped, no VCS connection", nil
+				}
+				_, err := w.c.SourceClient.Workspaces.RemoveVCSConnectionByID(w.c.SourceContext, w.src.ID)
+				if err != nil {
+					return "", err
+				}
+				return "removed " + w.src.VCSRepo.Identifier, nil
+			},
+			revert: func() error {
+				if w.src.VCSRepo == nil {
+					return nil
+				}
+				srcVCSID := w.src.VCSRepo.OAuthTokenID
+				if srcVCSID == "" {
+					srcVCSID = w.src.VCSRepo.GHAInstallationID
+				}
+				vcsOptions, ok := getVCSRepoOptions(w.src.VCSRepo, srcVCSID)
+				if !ok {
+					return fmt.Errorf("the VCS connection of the source workspace could not be restored, reconnect %v manually", w.src.VCSRepo.Identifier)
+				}
+				_, err := w.c.SourceClient.Workspaces.UpdateByID(w.c.SourceContext, w.src.ID, tfe.WorkspaceUpdateOptions{VCSRepo: &vcsOptions})
+				return err
+			},
+		},
+		{
+			name: "Enable destination VCS connection",
+			run: func() (string, error) {
+				if w.src.VCSRepo == nil {
+					return "skipped, no VCS connection", nil
+				}
+				vcsOptions, ok := getVCSRepoOptions(w.src.VCSRepo, w.destVCSID)
+				if !ok {
+					return "", fmt.Errorf("invalid destination VCS ID %q", w.destVCSID)
+				}
+				if _, err := configureVCSsettings(w.c, w.c.DestinationOrganizationName, vcsOptions, w.destName); err != nil {
+					return "", err
+				}
+				return "connected " + w.src.VCSRepo.Identifier + " with " + w.destVCSID, nil
+			},
+			revert: func() error {
+				if w.src.VCSRepo == nil {
+					return nil
+				}
+				_, err := w.c.DestinationClient.Workspaces.RemoveVCSConnectionByID(w.c.DestinationContext, w.destID)
+				return err
+			},
+		},
+		{
+			name: "Unlock destination workspace",
+			run: func() (string, error) {
+				// A destination workspace that was locked before the cutover, for example by an operator, stays locked
+				if !w.destLocked {
+					return "skipped, locked before the cutover", nil
+				}
+				if _, err := w.c.DestinationClient.Workspaces.Unlock(w.c.DestinationContext, w.destID); err != nil && err != tfe.ErrWorkspaceNotLocked {
+					return "", err
+				}
+				return "unlocked", nil
+			},
+		},
+	}
+}
+
+// Checks that the current destination state is the current source state by serial and MD5 checksum.
+// With state transforms the current destination state is the transformed state, so the source state
+// must exist in the destination and the current destination serial must not be lower.
+func (w *workspaceCutover) verifyState() (string, error) {
+	srcState, err := w.c.SourceClient.StateVersions.ReadCurrent(w.c.SourceContext, w.src.ID)
+	if err == tfe.ErrResourceNotFound {
+		return "skipped, no source state", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the current source state")
+	}
+
+	destState, err := w.c.DestinationClient.StateVersions.ReadCurrent(w.c.DestinationContext, w.destID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the current destination state")
+	}
+
+	if !w.sc.transforms.Empty() {
+		destStates, err := discoverDestStates(w.c, w.destName)
+		if err != nil {
+			return "", err
+		}
+		exists, _, err := doesStateExist(w.c, srcState, destStates)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return "", fmt.Errorf("source state serial %v does not exist in the destination", srcState.Serial)
+		}
+		if destState.Serial < srcState.Serial {
+			return "", fmt.Errorf("destination serial %v is lower than source serial %v", destState.Serial, srcState.Serial)
+		}
+		return fmt.Sprintf("source serial %v copied, destination serial %v is transformed", srcState.Serial, destState.Serial), nil
+	}
+
+	if destState.Serial != srcState.Serial {
+		return "", fmt.Errorf("destination serial %v does not match source serial %v", destState.Serial, srcState.Serial)
+	}
+
+	srcMD5, err := tfstate.DownloadMD5(w.c.SourceContext, w.c.SourceClient, srcState.DownloadURL)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to download the current source state")
+	}
+	destMD5, err := tfstate.DownloadMD5(w.c.DestinationContext, w.c.DestinationClient, destState.DownloadURL)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to download the current destination state")
+	}
+	if srcMD5 != destMD5 {
+		return "", fmt.Errorf("destination MD5 %v does not match source MD5 %v", destMD5, srcMD5)
+	}
+
+	return fmt.Sprintf("serial %v MD5 %v", destState.Serial, destMD5), nil
+}
+
+// Locks a workspace unless it is already locked. Sets locked to true if the workspace was locked by tfm.
+func lockCutoverWorkspace(ctx context.Context, client *tfe.Client, workspaceID string, locked *bool) (string, error) {
+	ws, err := client.Workspaces.ReadByID(ctx, workspaceID)
+	if err != nil {
+		return "", err
+	}
+	if ws.Locked {
+		return "already locked", nil
+	}
+
+	message := "tfm cutover"
+	if _, err := client.Workspaces.Lock(ctx, workspaceID, tfe.WorkspaceLockOptions{Reason: &message}); err != nil {
+		return "", err
+	}
+	*locked = true
+
+	return "locked", nil
+}
+
+// Unlocks a workspace if it was locked by tfm
+func unlockCutoverWorkspace(ctx context.Context, client *tfe.Client, workspaceID string, locked bool) error {
+	if !locked {
+		return nil
+	}
+	_, err := client.Workspaces.Unlock(ctx, workspaceID)
+	return err
+}
+
+// Runs cutover steps. If a step fails the steps done so far are reverted in reverse order.
+// Returns false if a step failed.
+func (w *workspaceCutover) runSteps(steps []cutoverStep, done *[]cutoverStep) bool {
+	for _, step := range steps {
+		fmt.Printf("%v: %v...\n", w.src.Name, step.name)
+
+		status, err := step.run()
+		if err != nil {
+			w.addStatus(step.name, "failed: "+err.Error())
+			w.revert(*done)
+			return false
+		}
+
+		w.addStatus(step.name, status)
+		*done = append(*done, step)
+	}
+	return true
+}
+
+// Reverts the steps done in reverse order
+func (w *workspaceCutover) revert(done []cutoverStep) {
+	for i := len(done) - 1; i >= 0; i-- {
+		step := done[i]
+		if step.revert == nil {
+			continue
+		}
+
+		fmt.Printf("%v: Reverting %v...\n", w.src.Name, step.name)
+		if err := step.revert(); err != nil {
+			w.addStatus(step.name, "revert failed: "+err.Error())
+		} else {
+			w.addStatus(step.name, "reverted")
+		}
+	}
+}
+
+// Prints the status of a step and adds it to the output table
+func (w *workspaceCutover) addStatus(step string, status string) {
+	fmt.Printf("%v: %v: %v\n", w.src.Name, step, status)
+	o.AddTableRows(w.src.Name, w.destName, step, status)
+}
+
+// Asks the user to confirm the point of no return of a workspace cutover unless --autoapprove is set
+func confirmCutover(cmd *cobra.Command, w *workspaceCutover) bool {
+	var input string
+
+	fmt.Printf("\n**** POINT OF NO RETURN ****\nThe missing states of source workspace %v will be copied, its VCS connection will be removed and destination workspace %v will be connected and unlocked. Copied states can not be reverted.\n", w.src.Name, w.destName)
+	fmt.Printf("Do you want to continue with this operation? [y|n]: ")
+
+	auto, err := cmd.Flags().GetBool("autoapprove")
+	if err != nil {
+		fmt.Println("Error Retrieving autoapprove flag value: ", err)
+	}
+
+	// Check if --autoapprove=false
+	if !auto {
+		_, err := fmt.Scanln(&input)
+		if err != nil {
+			return false
+		}
+	} else {
+		input = "y"
+		fmt.Println("y(autoapprove=true)")
+	}
+
+	input = strings.ToLower(input)
+
+	return input == "y" || input == "yes"
+}
+
+// Checks that a workspace can be cut over. Returns the cutover or the reason it can not be cut over.
+func newWorkspaceCutover(c tfclient.ClientContexts, sc *stateCopy, src *tfe.Workspace, destName string, vcsMapCfg map[string]string) (*workspaceCutover, error) {
+	w := &workspaceCutover{c: c, sc: sc, src: src, destName: destName}
+
+	destID, err := getWorkspaceId(c, destName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "destination workspace %v not found", destName)
+	}
+	w.destID = destID
+
+	if src.VCSRepo != nil {
+		srcVCSID := src.VCSRepo.OAuthTokenID
+		if srcVCSID == "" {
+			srcVCSID = src.VCSRepo.GHAInstallationID
+		}
+
+		w.destVCSID = vcsMapCfg[srcVCSID]
+		if w.destVCSID == "" {
+			return nil, fmt.Errorf("no destination VCS ID for source VCS ID %v in vcs-map", srcVCSID)
+		}
+		if _, ok := getVCSRepoOptions(src.VCSRepo, w.destVCSID); !ok {
+			return nil, fmt.Errorf("invalid destination VCS ID %v in vcs-map", w.destVCSID)
+		}
+	}
+
+	return w, nil
+}
+
+// Main function for `tfm cutover`
+func cutover(cmd *cobra.Command, c tfclient.ClientContexts) error {
+	wsMapCfg, err := helper.ViperStringSliceMap("workspaces-map")
+	if err != nil {
+		return errors.New("Invalid input for workspaces-map")
+	}
+
+	// A cutover is run for a wave of workspaces and never for all workspaces
+	if len(viper.GetStringSlice("workspaces")) == 0 && len(wsMapCfg) == 0 {
+		return errors.New("'workspaces' list or 'workspaces-map' must be defined in the config file to cut workspaces over")
+	}
+
+	vcsMapCfg, err := helper.ViperStringSliceMap("vcs-map")
+	if err != nil {
+		return errors.New("Invalid input for vcs-map")
+	}
+
+	srcWorkspaces, err := getSrcWorkspacesCfg(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Workspaces from source")
+	}
+
+	sc, err := newStateCopy(0)
+	if err != nil {
+		return err
+	}
+
+	o.AddTableHeaders("Source Workspace", "Destination Workspace", "Step", "Status")
+
+	var cutOver, failed []interface{}
+
+	for _, srcworkspace := range srcWorkspaces {
+		destWorkSpaceName := srcworkspace.Name
+		if len(wsMapCfg) > 0 {
+			destWorkSpaceName = wsMapCfg[srcworkspace.Name]
+		}
+
+		fmt.Printf("\nCutting over source workspace %v to destination workspace %v\n", srcworkspace.Name, destWorkSpaceName)
+
+		w, err := newWorkspaceCutover(c, sc, srcworkspace, destWorkSpaceName, vcsMapCfg)
+		if err != nil {
+			o.AddTableRows(srcworkspace.Name, destWorkSpaceName, "Check workspace", "failed: "+err.Error())
+			failed = append(failed, fmt.Sprintf("%v: %v", srcworkspace.Name, err))
+			continue
+		}
+
+		var done []cutoverStep
+		if !w.runSteps(w.prepareSteps(), &done) {
+			failed = append(failed, srcworkspace.Name)
+			continue
+		}
+
+		if !confirmCutover(cmd, w) {
+			w.addStatus("Point of no return", "canceled")
+			w.revert(done)
+			failed = append(failed, srcworkspace.Name)
+			continue
+		}
+
+		if !w.runSteps(w.switchSteps(), &done) {
+			failed = append(failed, srcworkspace.Name)
+			continue
+		}
+
+		cutOver = append(cutOver, fmt.Sprintf("%v -> %v", srcworkspace.Name, destWorkSpaceName))
+	}
+
+	if sc.backup != nil {
+		o.AddDeferredMessageRead("Destination states were backed up before the cutover to", sc.backup.Dir)
+	}
+
+	if len(cutOver) > 0 {
+		o.AddDeferredListMessageRead("Workspaces cut over. The source workspaces stay locked", cutOver)
+	}
+
+	if len(failed) > 0 {
+		o.AddDeferredListMessageRead("Workspaces not cut over", failed)
+	}
+
+	if len(sc.failed) > 0 {
+		o.AddDeferredListMessageRead("Failed state migrations", sc.failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,480 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	orgSettings     []string
+	orgSettingsPlan bool
+
+	// `tfm copy organization-settings` command
+	orgSettingsCopyCmd = &cobra.Command{
+		Use:     "organization-settings",
+		Aliases: []string{"org-settings"},
+		Short:   "Copy Organization settings",
+		Long:    "Compare source and destination Organization settings and copy the differing settings to the destination org",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return copyOrganizationSettings(
+				tfclient.GetClientContexts(), orgSettings, orgSettingsPlan)
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	orgSettingsCopyCmd.Flags().StringSliceVarP(&orgSettings, "settings", "", []string{}, "Comma separated list of settings to copy. Defaults to all differing settings")
+	orgSettingsCopyCmd.Flags().BoolVarP(&orgSettingsPlan, "plan", "", false, "Only show the differences between the source and destination Organization settings")
+
+	// Add commands
+	CopyCmd.AddCommand(orgSettingsCopyCmd)
+}
+
+// An Organization setting that can be compared between the source and destination
+type orgSetting struct {
+	Name        string
+	Source      string
+	Destination string
+
+	// Set when the setting cannot be copied, explains why
+	Unavailable string
+
+	// Returns false if the destination edition is not entitled to the setting
+	Entitled func(e *tfe.Entitlements) bool
+
+	// Sets the source value on the destination update options. Nil if the setting is unavailable
+	Apply func(opts *tfe.OrganizationUpdateOptions)
+
+	// Copies a setting that is not part of the Organization update options. Used instead of Apply
+	Update func(c tfclient.ClientContexts) error
+}
+
+// Builds the list of comparable Organization settings from the source and destination orgs.
+// The default agent pool is translated with the `agents-map` or by matching agent pool names.
+func buildOrgSettings(c tfclient.ClientContexts, src *tfe.Organization, dest *tfe.Organization) ([]*orgSetting, error) {
+
+	srcAgentPool := ""
+	destAgentPool := ""
+	mappedAgentPool := ""
+
+	if src.DefaultAgentPool != nil {
+		srcAgentPool = src.DefaultAgentPool.ID
+
+		var err error
+		mappedAgentPool, err = getDestDefaultAgentPoolID(c, srcAgentPool)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dest.DefaultAgentPool != nil {
+		destAgentPool = dest.DefaultAgentPool.ID
+	}
+
+	agentPoolUnavailable := ""
+	switch {
+	case srcAgentPool == "":
+		agentPoolUnavailable = "no default agent pool in source"
+	case mappedAgentPool == "":
+		agentPoolUnavailable = "no matching agent pool in destination"
+	}
+
+	policyOverrides, err := buildPolicyOverridesSetting(c)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := []*orgSetting{
+		{
+			Name:        "default-execution-mode",
+			Source:      src.DefaultExecutionMode,
+			Destination: dest.DefaultExecutionMode,
+			Entitled: func(e *tfe.Entitlements) bool {
+				return src.DefaultExecutionMode != "agent" || e.Agents
+			},
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.DefaultExecutionMode = &src.DefaultExecutionMode
+				if src.DefaultExecutionMode == "agent" && mappedAgentPool != "" {
+					opts.DefaultAgentPool = &tfe.AgentPool{ID: mappedAgentPool}
+				}
+			},
+		},
+		{
+			Name:        "default-agent-pool",
+			Source:      mappedAgentPool,
+			Destination: destAgentPool,
+			Unavailable: agentPoolUnavailable,
+			Entitled: func(e *tfe.Entitlements) bool {
+				return e.Agents
+			},
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.DefaultAgentPool = &tfe.AgentPool{ID: mappedAgentPool}
+			},
+		},
+		{
+			Name:        "cost-estimation-enabled",
+			Source:      strconv.FormatBool(src.CostEstimationEnabled),
+			Destination: strconv.FormatBool(dest.CostEstimationEnabled),
+			Entitled: func(e *tfe.Entitlements) bool {
+				return e.CostEstimation
+			},
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.CostEstimationEnabled = &src.CostEstimationEnabled
+			},
+		},
+		{
+			Name:        "assessments-enforced",
+			Source:      strconv.FormatBool(src.AssessmentsEnforced),
+			Destination: strconv.FormatBool(dest.AssessmentsEnforced),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.AssessmentsEnforced = &src.AssessmentsEnforced
+			},
+		},
+		{
+			Name:        "session-timeout",
+			Source:      strconv.Itoa(src.SessionTimeout),
+			Destination: strconv.Itoa(dest.SessionTimeout),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.SessionTimeout = &src.SessionTimeout
+			},
+		},
+		{
+			Name:        "session-remember",
+			Source:      strconv.Itoa(src.SessionRemember),
+			Destination: strconv.Itoa(dest.SessionRemember),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.SessionRemember = &src.SessionRemember
+			},
+		},
+		{
+			Name:        "collaborator-auth-policy",
+			Source:      string(src.CollaboratorAuthPolicy),
+			Destination: string(dest.CollaboratorAuthPolicy),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.CollaboratorAuthPolicy = &src.CollaboratorAuthPolicy
+			},
+		},
+		{
+			Name:        "owners-team-saml-role-id",
+			Source:      src.OwnersTeamSAMLRoleID,
+			Destination: dest.OwnersTeamSAMLRoleID,
+			Entitled: func(e *tfe.Entitlements) bool {
+				return e.SSO
+			},
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.OwnersTeamSAMLRoleID = &src.OwnersTeamSAMLRoleID
+			},
+		},
+		{
+			Name:        "send-passing-statuses-for-untriggered-speculative-plans",
+			Source:      strconv.FormatBool(src.SendPassingStatusesForUntriggeredSpeculativePlans),
+			Destination: strconv.FormatBool(dest.SendPassingStatusesForUntriggeredSpeculativePlans),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.SendPassingStatusesForUntriggeredSpeculativePlans = &src.SendPassingStatusesForUntriggeredSpeculativePlans
+			},
+		},
+		{
+			Name:        "aggregated-commit-status-enabled",
+			Source:      strconv.FormatBool(src.AggregatedCommitStatusEnabled),
+			Destination: strconv.FormatBool(dest.AggregatedCommitStatusEnabled),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.AggregatedCommitStatusEnabled = &src.AggregatedCommitStatusEnabled
+			},
+		},
+		{
+			Name:        "speculative-plan-management-enabled",
+			Source:      strconv.FormatBool(src.SpeculativePlanManagementEnabled),
+			Destination: strconv.FormatBool(dest.SpeculativePlanManagementEnabled),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.SpeculativePlanManagementEnabled = &src.SpeculativePlanManagementEnabled
+			},
+		},
+		{
+			Name:        "allow-force-delete-workspaces",
+			Source:      strconv.FormatBool(src.AllowForceDeleteWorkspaces),
+			Destination: strconv.FormatBool(dest.AllowForceDeleteWorkspaces),
+			Apply: func(opts *tfe.OrganizationUpdateOptions) {
+				opts.AllowForceDeleteWorkspaces = &src.AllowForceDeleteWorkspaces
+			},
+		},
+		policyOverrides,
+	}
+
+	// The default agent pool cannot be set if the source pool has no match in the destination
+	if srcAgentPool != "" && mappedAgentPool == "" {
+		o.AddFormattedMessageUserProvided2("Source default Agent Pool %v has no match in destination org %v. Run `tfm copy agent-pools` first.", srcAgentPool, c.DestinationOrganizationName)
+	}
+
+	return settings, nil
+}
+
+// Lists the policy sets of an organization
+func discoverPolicySets(ctx context.Context, client *tfe.Client, org string) ([]*tfe.PolicySet, error) {
+	policySets := []*tfe.PolicySet{}
+
+	opts := tfe.PolicySetListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		items, err := client.PolicySets.List(ctx, org, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		policySets = append(policySets, items.Items...)
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return policySets, nil
+}
+
+// Returns the sorted names of the policy sets that allow overrides, or "none"
+func overridableNames(policySets map[string]*tfe.PolicySet) string {
+	names := []string{}
+	for name, ps := range policySets {
+		if *ps.Overridable {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// Builds the setting of the Sentinel and OPA policy sets that allow users to override failed
+// policies during a run. Policy sets are matched by name, only policy sets that exist in both
+// orgs and report whether they are overridable are compared.
+func buildPolicyOverridesSetting(c tfclient.ClientContexts) (*orgSetting, error) {
+	setting := &orgSetting{
+		Name: "policy-workspace-overrides",
+		Entitled: func(e *tfe.Entitlements) bool {
+			return e.Sentinel
+		},
+	}
+
+	srcPolicySets, err := discoverPolicySets(c.SourceContext, c.SourceClient, c.SourceOrganizationName)
+	if err != nil {
+		setting.Unavailable = "source policy sets could not be listed"
+		return setting, nil
+	}
+	destPolicySets, err := discoverPolicySets(c.DestinationContext, c.DestinationClient, c.DestinationOrganizationName)
+	if err != nil {
+		setting.Unavailable = "destination policy sets could not be listed"
+		return setting, nil
+	}
+
+	destByName := map[string]*tfe.PolicySet{}
+	for _, ps := range destPolicySets {
+		if ps.Overridable != nil {
+			destByName[ps.Name] = ps
+		}
+	}
+
+	src := map[string]*tfe.PolicySet{}
+	dest := map[string]*tfe.PolicySet{}
+	for _, ps := range srcPolicySets {
+		if ps.Overridable == nil {
+			continue
+		}
+		destPS, exists := destByName[ps.Name]
+		if !exists {
+			if *ps.Overridable {
+				o.AddFormattedMessageUserProvided2("Source policy set %v allows overrides and has no match in destination org %v.", ps.Name, c.DestinationOrganizationName)
+			}
+			continue
+		}
+		src[ps.Name] = ps
+		dest[ps.Name] = destPS
+	}
+
+	setting.Source = overridableNames(src)
+	setting.Destination = overridableNames(dest)
+	setting.Update = func(c tfclient.ClientContexts) error {
+		for name, ps := range src {
+			if *ps.Overridable == *dest[name].Overridable {
+				continue
+			}
+			_, err := c.DestinationClient.PolicySets.Update(c.DestinationContext, dest[name].ID, tfe.PolicySetUpdateOptions{
+				Overridable: ps.Overridable,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "policy set %v", name)
+			}
+		}
+		return nil
+	}
+
+	return setting, nil
+}
+
+// Finds the destination agent pool ID for a source agent pool ID using the `agents-map`
+// or by matching the agent pool names.
+func getDestDefaultAgentPoolID(c tfclient.ClientContexts, srcPoolID string) (string, error) {
+	agentsMapCfg, err := helper.ViperStringSliceMap("agents-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for agents-map")
+	}
+
+	if destPoolID, ok := agentsMapCfg[srcPoolID]; ok {
+		return destPoolID, nil
+	}
+
+	srcPool, err := c.SourceClient.AgentPools.Read(c.SourceContext, srcPoolID)
+	if err != nil {
+		return "", err
+	}
+
+	destPools, err := discoverDestAgentPools(c)
+	if err != nil {
+		return "", err
+	}
+
+	if destPool, exists := getAgentPoolByName(srcPool.Name, destPools); exists {
+		return destPool.ID, nil
+	}
+
+	return "", nil
+}
+
+// Check if a setting was chosen by the user. All settings are chosen if none were provided.
+func isOrgSettingSelected(name string, selected []string) bool {
+	if len(selected) == 0 {
+		return true
+	}
+	for _, s := range selected {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Main function for `tfm copy organization-settings`
+// Compares the source and destination Organization settings, shows the plan
+// and updates the chosen differing settings in the destination.
+func copyOrganizationSettings(c tfclient.ClientContexts, selected []string, planOnly bool) error {
+
+	srcOrg, err := c.SourceClient.Organizations.Read(c.SourceContext, c.SourceOrganizationName)
+	if err != nil {
+		return errors.Wrap(err, "failed to read source Organization")
+	}
+
+	destOrg, err := c.DestinationClient.Organizations.Read(c.DestinationContext, c.DestinationOrganizationName)
+	if err != nil {
+		return errors.Wrap(err, "failed to read destination Organization")
+	}
+
+	// Entitlements are not available on all TFE releases. Assume entitled if they cannot be read.
+	entitlements, err := c.DestinationClient.Organizations.ReadEntitlements(c.DestinationContext, c.DestinationOrganizationName)
+	if err != nil {
+		o.AddErrorUserProvided("Unable to read destination Organization entitlements. Entitlement checks will be skipped.")
+		entitlements = nil
+	}
+
+	settings, err := buildOrgSettings(c, srcOrg, destOrg)
+	if err != nil {
+		return errors.Wrap(err, "failed to compare Organization settings")
+	}
+
+	for _, s := range selected {
+		found := false
+		for _, setting := range settings {
+			if setting.Name == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("unknown Organization setting " + s)
+		}
+	}
+
+	var toApply []*orgSetting
+
+	o.AddMessageUserProvided3("Comparing Organization settings of source", c.SourceOrganizationName, "with destination", c.DestinationOrganizationName)
+	o.AddTableHeaders("Setting", "Source", "Destination", "Action")
+
+	for _, setting := range settings {
+		action := ""
+
+		switch {
+		case !isOrgSettingSelected(setting.Name, selected):
+			action = "not selected"
+		case setting.Unavailable != "":
+			action = setting.Unavailable
+		case setting.Source == setting.Destination:
+			action = "in sync"
+		case entitlements != nil && setting.Entitled != nil && !setting.Entitled(entitlements):
+			action = "not entitled in destination"
+		default:
+			action = "update"
+			toApply = append(toApply, setting)
+		}
+
+		o.AddTableRows(setting.Name, setting.Source, setting.Destination, action)
+	}
+
+	// Print the plan before asking for confirmation
+	o.Close()
+
+	if len(toApply) == 0 {
+		o.AddPassUserProvided("Destination Organization settings match the source. Nothing to copy.")
+		return nil
+	}
+
+	if planOnly {
+		return nil
+	}
+
+	if !confirm() {
+		fmt.Println("\n\n**** Canceling tfm run **** ")
+		os.Exit(1)
+	}
+
+	// Update each setting on its own so a setting the destination does not support
+	// does not prevent the others from being copied.
+	for _, setting := range toApply {
+		var err error
+		if setting.Update != nil {
+			err = setting.Update(c)
+		} else {
+			opts := tfe.OrganizationUpdateOptions{
+				Type: "",
+			}
+			setting.Apply(&opts)
+
+			_, err = c.DestinationClient.Organizations.Update(c.DestinationContext, c.DestinationOrganizationName, opts)
+		}
+		if err != nil {
+			o.AddErrorUserProvided3("Destination rejected setting", setting.Name+":", err.Error())
+			o.AddDeferredMessageRead("Rejected by destination", setting.Name)
+			continue
+		}
+
+		o.AddDeferredMessageRead("Copied", setting.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// All functions related to copying project settings that are not part of the project create options
+
+// Project default execution settings. go-tfe does not expose these attributes yet, so the
+// project is read and updated with these minimal JSON:API models.
+type projectExecutionSettings struct {
+	ID                   string         `jsonapi:"primary,projects"`
+	DefaultExecutionMode string         `jsonapi:"attr,default-execution-mode,omitempty"`
+	DefaultAgentPool     *tfe.AgentPool `jsonapi:"relation,default-agent-pool,omitempty"`
+}
+
+// Get the source project tag bindings. Releases without project tags return an error,
+// in which case the project is created without tags.
+func discoverSrcProjTagBindings(c tfclient.ClientContexts, srcproject *tfe.Project) []*tfe.TagBinding {
+	bindings, err := c.SourceClient.Projects.ListTagBindings(c.SourceContext, srcproject.ID)
+	if err != nil {
+		o.AddMessageUserProvided2("Unable to read tags of source Project", srcproject.Name, err.Error())
+		return nil
+	}
+
+	// Only the key and value are sent to the destination
+	tags := []*tfe.TagBinding{}
+	for _, b := range bindings {
+		tags = append(tags, &tfe.TagBinding{Key: b.Key, Value: b.Value})
+	}
+
+	return tags
+}
+
+// Returns true if the destination rejected a request because it does not know one of its attributes.
+// go-tfe returns the JSON:API error titles and details of a 422 response without the status code.
+func isUnsupportedAttributeError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, phrase := range []string{"unpermitted", "unknown attribute", "not a valid attribute", "unsupported attribute", "is not supported"} {
+		if strings.Contains(message, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// Creates the destination project with the description, tags and auto-destroy activity duration of the
+// source project. Releases that do not support one of these attributes reject the whole request, the
+// project is then created with its name only and each attribute is set on its own. The attributes the
+// destination rejects are reported. Any other error, such as a name conflict, is returned.
+func createDestProject(c tfclient.ClientContexts, srcproject *tfe.Project, destProjectName string) (*tfe.Project, error) {
+	tagBindings := discoverSrcProjTagBindings(c, srcproject)
+
+	destproject, err := c.DestinationClient.Projects.Create(c.DestinationContext, c.DestinationOrganizationName, tfe.ProjectCreateOptions{
+		Type:                        "",
+		Name:                        destProjectName,
+		Description:                 &srcproject.Description,
+		TagBindings:                 tagBindings,
+		AutoDestroyActivityDuration: srcproject.AutoDestroyActivityDuration,
+	})
+	if err == nil {
+		return destproject, nil
+	}
+	if !isUnsupportedAttributeError(err) {
+		return nil, err
+	}
+
+	destproject, retryErr := c.DestinationClient.Projects.Create(c.DestinationContext, c.DestinationOrganizationName, tfe.ProjectCreateOptions{
+		Type: "",
+		Name: destProjectName,
+	})
+	if retryErr != nil {
+		return nil, err
+	}
+	o.AddMessageUserProvided2("Destination does not support the settings of Project", destProjectName, "Created it without them: "+err.Error())
+
+	settings := []struct {
+		name string
+		set  bool
+		opts tfe.ProjectUpdateOptions
+	}{
+		{"description", srcproject.Description != "", tfe.ProjectUpdateOptions{Description: &srcproject.Description}},
+		{"tags", len(tagBindings) > 0, tfe.ProjectUpdateOptions{TagBindings: tagBindings}},
+		{"auto-destroy-activity-duration", srcproject.AutoDestroyActivityDuration.IsSpecified(), tfe.ProjectUpdateOptions{AutoDestroyActivityDuration: srcproject.AutoDestroyActivityDuration}},
+	}
+
+	unsupported := []string{}
+	for _, setting := range settings {
+		if !setting.set {
+			continue
+		}
+		if _, err := c.DestinationClient.Projects.Update(c.DestinationContext, destproject.ID, setting.opts); err != nil {
+			unsupported = append(unsupported, setting.name)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		o.AddDeferredMessageRead("Settings not supported by destination for Project "+destProjectName, strings.Join(unsupported, ", "))
+	}
+
+	return destproject, nil
+}
+
+// Read the default execution settings of a source project. An empty execution mode means the
+// source does not support project default execution settings.
+func discoverSrcProjExecutionSettings(c tfclient.ClientContexts, srcProjectID string) (*projectExecutionSettings, error) {
+	req, err := c.SourceClient.NewRequest("GET", fmt.Sprintf("projects/%s", url.PathEscape(srcProjectID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &projectExecutionSettings{}
+	err = req.Do(c.SourceContext, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// Copies the default execution mode and agent pool of the source project to the destination project.
+// The agent pool is translated with the `agents-map` or by matching agent pool names.
+func copyProjExecutionSettings(c tfclient.ClientContexts, srcproject *tfe.Project, destProjectID string, destProjectName string) error {
+	srcSettings, err := discoverSrcProjExecutionSettings(c, srcproject.ID)
+	if err != nil {
+		return err
+	}
+
+	if srcSettings.DefaultExecutionMode == "" {
+		o.AddMessageUserProvided("Source does not return default execution settings, skipping for Project:", srcproject.Name)
+		return nil
+	}
+
+	opts := &projectExecutionSettings{
+		DefaultExecutionMode: srcSettings.DefaultExecutionMode,
+	}
+
+	if srcSettings.DefaultExecutionMode == "agent" && srcSettings.DefaultAgentPool != nil {
+		destPoolID, err := getDestDefaultAgentPoolID(c, srcSettings.DefaultAgentPool.ID)
+		if err != nil {
+			return err
+		}
+
+		if destPoolID == "" {
+			o.AddMessageUserProvided2("No matching destination agent pool for", srcSettings.DefaultAgentPool.ID, "skipping default execution settings of Project "+destProjectName)
+			return nil
+		}
+		opts.DefaultAgentPool = &tfe.AgentPool{ID: destPoolID}
+	}
+
+	req, err := c.DestinationClient.NewRequest("PATCH", fmt.Sprintf("projects/%s", url.PathEscape(destProjectID)), opts)
+	if err != nil {
+		return err
+	}
+
+	err = req.Do(c.DestinationContext, nil)
+	if err != nil {
+		o.AddErrorUserProvided2("Destination does not support project default execution settings for "+destProjectName+":", err.Error())
+		return nil
+	}
+
+	o.AddMessageUserProvided2("Set default execution mode", srcSettings.DefaultExecutionMode, "on Project "+destProjectName)
+
+	return nil
+}
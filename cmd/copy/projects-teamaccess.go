@@ -0,0 +1,250 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+)
+
+// All functions related to copying project team access
+
+// Get source project team access permissions.
+func discoverSrcProjTeamAccess(c tfclient.ClientContexts, projId string, projName string) ([]*tfe.TeamProjectAccess, error) {
+	o.AddMessageUserProvided("Getting Project Team access permissions from source Project ", projName)
+	srcTeamAccess := []*tfe.TeamProjectAccess{}
+
+	opts := tfe.TeamProjectAccessListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+		ProjectID:   projId,
+	}
+	for {
+		items, err := c.SourceClient.TeamProjectAccess.List(c.SourceContext, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		srcTeamAccess = append(srcTeamAccess, items.Items...)
+
+		o.AddFormattedMessageCalculated("Found %d sets of Project Team access permissions", len(srcTeamAccess))
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+
+	}
+
+	return srcTeamAccess, nil
+}
+
+// Get destination project team access permissions.
+func discoverDestProjTeamAccess(c tfclient.ClientContexts, projId string, projName string) ([]*tfe.TeamProjectAccess, error) {
+	o.AddMessageUserProvided("Getting Project Team access permissions from destination Project ", projName)
+	destTeamAccess := []*tfe.TeamProjectAccess{}
+
+	opts := tfe.TeamProjectAccessListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+		ProjectID:   projId,
+	}
+	for {
+		items, err := c.DestinationClient.TeamProjectAccess.List(c.DestinationContext, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		destTeamAccess = append(destTeamAccess, items.Items...)
+
+		o.AddFormattedMessageCalculated("Found %d sets of Project Team access permissions", len(destTeamAccess))
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+
+	}
+
+	return destTeamAccess, nil
+}
+
+// Takes a project name and a slice of projects and returns the matching project.
+func getProjectByName(projectName string, projects []*tfe.Project) (*tfe.Project, bool) {
+	for _, p := range projects {
+		if projectName == p.Name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Check the destination Project Team access permissions for existing permissions of a team.
+func doesProjTeamAccessExist(destTeamId string, destTeamAccess []*tfe.TeamProjectAccess) bool {
+	for _, a := range destTeamAccess {
+		if a.Team != nil && a.Team.ID == destTeamId {
+			return true
+		}
+	}
+	return false
+}
+
+// Project access permissions creation. The project and workspace permission sets are only
+// sent when Access is 'custom'; otherwise, they reflect the Access level's implicit permissions.
+func createProjTeamAccess(c tfclient.ClientContexts, srcTeamName string, destTeamId string, destProjectId string, srcteam *tfe.TeamProjectAccess) error {
+	o.AddMessageUserProvided("Migrating Project Team access permissions for: ", srcTeamName)
+
+	opts := tfe.TeamProjectAccessAddOptions{
+		Type:   "",
+		Access: srcteam.Access,
+		Team: &tfe.Team{
+			ID: destTeamId,
+		},
+		Project: &tfe.Project{
+			ID: destProjectId,
+		},
+	}
+
+	if srcteam.Access == tfe.TeamProjectAccessCustom {
+		if srcteam.ProjectAccess != nil {
+			opts.ProjectAccess = &tfe.TeamProjectAccessProjectPermissionsOptions{
+				Settings:     &srcteam.ProjectAccess.ProjectSettingsPermission,
+				Teams:        &srcteam.ProjectAccess.ProjectTeamsPermission,
+				VariableSets: &srcteam.ProjectAccess.ProjectVariableSetsPermission,
+			}
+
+			// Older releases do not return the variable sets permission
+			if srcteam.ProjectAccess.ProjectVariableSetsPermission == "" {
+				opts.ProjectAccess.VariableSets = nil
+			}
+		}
+
+		if srcteam.WorkspaceAccess != nil {
+			opts.WorkspaceAccess = &tfe.TeamProjectAccessWorkspacePermissionsOptions{
+				Runs:          &srcteam.WorkspaceAccess.WorkspaceRunsPermission,
+				SentinelMocks: &srcteam.WorkspaceAccess.WorkspaceSentinelMocksPermission,
+				StateVersions: &srcteam.WorkspaceAccess.WorkspaceStateVersionsPermission,
+				Variables:     &srcteam.WorkspaceAccess.WorkspaceVariablesPermission,
+				Create:        &srcteam.WorkspaceAccess.WorkspaceCreatePermission,
+				Locking:       &srcteam.WorkspaceAccess.WorkspaceLockingPermission,
+				Move:          &srcteam.WorkspaceAccess.WorkspaceMovePermission,
+				Delete:        &srcteam.WorkspaceAccess.WorkspaceDeletePermission,
+				RunTasks:      &srcteam.WorkspaceAccess.WorkspaceRunTasksPermission,
+			}
+		}
+	}
+
+	_, err := c.DestinationClient.TeamProjectAccess.Add(c.DestinationContext, opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Main function for the `tfm copy projects --teamaccess` flag
+func copyProjTeamAccess(c tfclient.ClientContexts) error {
+
+	// Get the source Projects from the config file or ALL projects if none provided
+	srcProjects, err := getSrcProjectsCfg(c)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list Projects from source")
+	}
+
+	// Get/Check if Project map exists
+	projMapCfg, err := helper.ViperStringSliceMap("projects-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for projects-map")
+	}
+
+	// Get/Check if Team map exists
+	teamsMapCfg, err := helper.ViperStringSliceMap("teams-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for teams-map")
+	}
+
+	destProjects, err := listDestProjects(c, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list Projects from destination")
+	}
+
+	srcTeams, err := discoverSrcTeams(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list teams from source")
+	}
+
+	destTeams, err := discoverDestTeams(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list teams from destination")
+	}
+
+	for _, srcproject := range srcProjects {
+		destProjectName := srcproject.Name
+
+		// Check if the destination Project name differs from the source name
+		if len(projMapCfg) > 0 {
+			destProjectName = projMapCfg[srcproject.Name]
+		}
+
+		destproject, exists := getProjectByName(destProjectName, destProjects)
+		if !exists {
+			o.AddMessageUserProvided2(destProjectName, "does not exist in destination. No Team access to migrate for", srcproject.Name)
+			continue
+		}
+
+		srcTeamAccess, err := discoverSrcProjTeamAccess(c, srcproject.ID, srcproject.Name)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list Team Access for source Project")
+		}
+
+		destTeamAccess, err := discoverDestProjTeamAccess(c, destproject.ID, destproject.Name)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list Team Access for destination Project")
+		}
+
+		for _, srcaccess := range srcTeamAccess {
+			if srcaccess.Team == nil {
+				continue
+			}
+
+			// Get the source team name from the team ID
+			srcTeamName := ""
+			for _, t := range srcTeams {
+				if t.ID == srcaccess.Team.ID {
+					srcTeamName = t.Name
+					break
+				}
+			}
+
+			if srcTeamName == "" {
+				o.AddMessageUserProvided("Source Team not found for Team ID: ", srcaccess.Team.ID)
+				continue
+			}
+
+			// Check if the destination Team name differs from the source name
+			destTeamName := srcTeamName
+			if mapped, ok := teamsMapCfg[srcTeamName]; ok {
+				destTeamName = mapped
+			}
+
+			destteam, exists := getTeamByName(destTeamName, destTeams)
+			if !exists {
+				o.AddMessageUserProvided2(destTeamName, "Team does not exist in destination. Skipping Team access on Project", destproject.Name)
+				continue
+			}
+
+			if doesProjTeamAccessExist(destteam.ID, destTeamAccess) {
+				o.AddMessageUserProvided("Team access exists in destination Project, skipping migration for: ", destTeamName)
+				continue
+			}
+
+			err := createProjTeamAccess(c, srcTeamName, destteam.ID, destproject.ID, srcaccess)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create Team access for destination Project "+destproject.Name)
+			}
+			o.AddDeferredMessageRead("Migrated Team access for "+destTeamName+" on Project", destproject.Name)
+		}
+	}
+	return nil
+}
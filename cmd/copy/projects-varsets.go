@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"fmt"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+)
+
+// All functions related to applying variable sets to projects
+
+// Check if a variable set is applied to a project
+func isVarSetAppliedToProject(set *tfe.VariableSet, projectID string) bool {
+	for _, p := range set.Projects {
+		if p.ID == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// Main function for the `tfm copy projects --vars` flag
+// Applies the destination variable sets matching the source variable sets of each source project
+// to the destination project. Variable set names are translated with the `varsets-map`.
+func copyProjVarSets(c tfclient.ClientContexts) error {
+
+	// Get the source Projects from the config file or ALL projects if none provided
+	srcProjects, err := getSrcProjectsCfg(c)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list Projects from source")
+	}
+
+	// Get/Check if Project map exists
+	projMapCfg, err := helper.ViperStringSliceMap("projects-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for projects-map")
+	}
+
+	// Get/Check if Variable Set map exists
+	varSetsMapCfg, err := helper.ViperStringSliceMap("varsets-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for varsets-map")
+	}
+
+	destProjects, err := listDestProjects(c, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list Projects from destination")
+	}
+
+	srcVarSets, err := discoverSrcVariableSets(c, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to list variable sets from source")
+	}
+
+	destVarSets, err := discoverDestVariableSets(c, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to list variable sets from destination")
+	}
+
+	var unmatched []interface{}
+
+	for _, srcproject := range srcProjects {
+		destProjectName := srcproject.Name
+
+		// Check if the destination Project name differs from the source name
+		if mapped, ok := projMapCfg[srcproject.Name]; ok {
+			destProjectName = mapped
+		}
+
+		destproject, exists := getProjectByName(destProjectName, destProjects)
+		if !exists {
+			o.AddMessageUserProvided2(destProjectName, "does not exist in destination. No Variable Sets to apply for", srcproject.Name)
+			continue
+		}
+
+		for _, srcset := range srcVarSets {
+			if srcset.Global || !isVarSetAppliedToProject(srcset, srcproject.ID) {
+				continue
+			}
+
+			destSetName := srcset.Name
+			if len(varSetsMapCfg) > 0 {
+				mapped, ok := varSetsMapCfg[srcset.Name]
+				if !ok {
+					o.AddMessageUserProvided2(srcset.Name, "is not in the varsets-map. Skipping for Project", destproject.Name)
+					continue
+				}
+				destSetName = mapped
+			}
+
+			var destset *tfe.VariableSet
+			for _, s := range destVarSets {
+				if s.Name == destSetName {
+					destset = s
+					break
+				}
+			}
+
+			if destset == nil {
+				unmatched = append(unmatched, fmt.Sprintf("variable set %v for project %v", destSetName, destproject.Name))
+				continue
+			}
+
+			if isVarSetAppliedToProject(destset, destproject.ID) {
+				o.AddMessageUserProvided2("Variable Set", destSetName, "is already applied to Project "+destproject.Name)
+				continue
+			}
+
+			err := c.DestinationClient.VariableSets.ApplyToProjects(c.DestinationContext, destset.ID, tfe.VariableSetApplyToProjectsOptions{
+				Projects: []*tfe.Project{{ID: destproject.ID}},
+			})
+			if err != nil {
+				return errors.Wrap(err, "Failed to apply Variable Set "+destSetName+" to Project "+destproject.Name)
+			}
+			o.AddDeferredMessageRead("Applied Variable Set "+destSetName+" to Project", destproject.Name)
+		}
+	}
+
+	if len(unmatched) > 0 {
+		o.AddDeferredListMessageRead("Variable Sets missing in destination, run `tfm copy varsets` first", unmatched)
+	}
+
+	return nil
+}
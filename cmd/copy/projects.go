@@ -36,12 +36,18 @@ var (
 			// Continue the application if `projects-map` is not provided. The valid and map output arent needed.
 			_ = valid
 
-			// switch {
-			// case vars:
-			// 	return copyVariables(tfclient.GetClientContexts())
-			// case teamaccess:
-			// 	return copyprojTeamAccess(tfclient.GetClientContexts())
-			// }
+			// --teamaccess and --vars can be combined, team access is copied first
+			if teamaccess || vars {
+				if teamaccess {
+					if err := copyProjTeamAccess(tfclient.GetClientContexts()); err != nil {
+						return err
+					}
+				}
+				if vars {
+					return copyProjVarSets(tfclient.GetClientContexts())
+				}
+				return nil
+			}
 
 			return copyProjects(
 				tfclient.GetClientContexts(), projMapCfg)
@@ -56,7 +62,8 @@ func init() {
 
 	// `tfemigrate copy projects --project-id [projectID]`
 	projectsCopyCmd.Flags().String("project-id", "", "Specify one single project ID to copy to destination")
-	projectsCopyCmd.Flags().BoolVarP(&vars, "vars", "", false, "Copy project variables")
+	projectsCopyCmd.Flags().BoolVarP(&vars, "vars", "", false, "Apply the matching destination variable sets to the destination projects")
+	projectsCopyCmd.Flags().BoolVarP(&teamaccess, "teamaccess", "", false, "Copy project Team Access")
 	projectsCopyCmd.Flags().SetInterspersed(false)
 
 	// Add commands
@@ -312,6 +319,7 @@ func doesProjectExist(projectName string, proj []*tfe.Project) bool {
 }
 
 // copyProject creates a new project in the target organization with settings from the source project.
+// Description, tags, auto-destroy activity duration and default execution settings are copied.
 func copyProjects(c tfclient.ClientContexts, projMapCfg map[string]string) error {
 
 	// Get Projects from Config OR get ALL Projects from source
@@ -341,16 +349,18 @@ func copyProjects(c tfclient.ClientContexts, projMapCfg map[string]string) error
 			o.AddMessageUserProvided2(destProjectName, "exists in destination will not migrate", srcproject.Name)
 		} else {
 
-			srcproject, err := c.DestinationClient.Projects.Create(c.DestinationContext, c.DestinationOrganizationName, tfe.ProjectCreateOptions{
-				Type: "",
-				Name: destProjectName,
-			})
-
+			destproject, err := createDestProject(c, srcproject, destProjectName)
 			if err != nil {
 				fmt.Println("Could not create Project.\n\n Error:", err.Error())
 				return err
 			}
-			o.AddDeferredMessageRead("Migrated", srcproject.Name)
+
+			err = copyProjExecutionSettings(c, srcproject, destproject.ID, destproject.Name)
+			if err != nil {
+				return errors.Wrap(err, "Failed to copy default execution settings of Project "+srcproject.Name)
+			}
+
+			o.AddDeferredMessageRead("Migrated", destproject.Name)
 		}
 	}
 	return nil
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"fmt"
+
+	"github.com/hashicorp-services/tfm/secrets"
+	"github.com/spf13/viper"
+)
+
+// All functions related to filling in sensitive variable values from a secret provider
+
+var secretsProvider string
+
+// Looks up sensitive variable values and keeps track of the ones left without a value.
+type secretLookup struct {
+	provider secrets.Provider
+	unset    []interface{}
+}
+
+// Creates the secret lookup for the `--secrets-provider` flag, or the `secrets-provider` config
+// when the flag is not set. Without a provider all sensitive variables are created without a
+// value and reported.
+func newSecretLookup(kind string) (*secretLookup, error) {
+	if kind == "" {
+		kind = viper.GetString("secrets-provider")
+	}
+
+	provider, err := secrets.NewProvider(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider != nil {
+		o.AddMessageUserProvided("Using secrets provider:", provider.Name())
+	}
+
+	return &secretLookup{provider: provider}, nil
+}
+
+// Sets the value of a sensitive variable owned by a workspace or variable set.
+// Returns false if the provider has no value for the variable.
+func (s *secretLookup) fill(owner string, key string, value *string) bool {
+	if secret, ok := s.get(owner, key); ok {
+		*value = secret
+		return true
+	}
+
+	s.unset = append(s.unset, fmt.Sprintf("%v/%v", owner, key))
+	return false
+}
+
+// Returns the value of a sensitive variable without tracking it as unset
+func (s *secretLookup) get(owner string, key string) (string, bool) {
+	if s.provider == nil {
+		return "", false
+	}
+
+	secret, ok, err := s.provider.Get(owner, key)
+	if err != nil {
+		o.AddErrorUserProvided2("Unable to get sensitive value for "+owner+"/"+key+":", err.Error())
+		return "", false
+	}
+
+	return secret, ok
+}
+
+// Lists the sensitive variables that were created without a value
+func (s *secretLookup) report() {
+	if len(s.unset) > 0 {
+		o.AddDeferredListMessageRead("Sensitive variables created without a value", s.unset)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+)
+
+// All functions related to copying team membership and pending invitations
+
+// Get all destination Organization memberships, including pending invitations
+func discoverDestOrgMemberships(c tfclient.ClientContexts) ([]*tfe.OrganizationMembership, error) {
+	o.AddMessageUserProvided("Getting list of Organization memberships from: ", c.DestinationHostname)
+	destMemberships := []*tfe.OrganizationMembership{}
+
+	opts := tfe.OrganizationMembershipListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100},
+	}
+	for {
+		items, err := c.DestinationClient.OrganizationMemberships.List(c.DestinationContext, c.DestinationOrganizationName, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		destMemberships = append(destMemberships, items.Items...)
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+
+	}
+
+	o.AddFormattedMessageCalculated("Found %d Organization memberships", len(destMemberships))
+
+	return destMemberships, nil
+}
+
+// Takes a team name and a slice of teams and returns the matching team.
+// Team names are compared case insensitive like doesTeamExist.
+func getTeamByName(teamName string, teams []*tfe.Team) (*tfe.Team, bool) {
+	for _, t := range teams {
+		if strings.EqualFold(teamName, t.Name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Returns the email of an Organization membership. Pending invitations may only
+// have the email set on the membership, confirmed members on the user.
+func getMembershipEmail(m *tfe.OrganizationMembership) string {
+	if m.Email != "" {
+		return m.Email
+	}
+	if m.User != nil {
+		return m.User.Email
+	}
+	return ""
+}
+
+// Main function for `tfm copy teams --members`
+// Resolves the source team members in the destination by email, or by the `users-map`
+// for changed addresses, invites missing users to the destination org and adds them
+// to the matching destination teams.
+func copyTeamMembers(c tfclient.ClientContexts) error {
+
+	// Get/Check if a users map exists
+	usersMapCfg, err := helper.ViperStringSliceMap("users-map")
+	if err != nil {
+		return errors.Wrap(err, "Invalid input for users-map")
+	}
+
+	// Lower case the map so emails are compared case insensitive
+	usersMap := make(map[string]string, len(usersMapCfg))
+	for k, v := range usersMapCfg {
+		usersMap[strings.ToLower(k)] = v
+	}
+
+	srcTeams, err := discoverSrcTeams(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list teams from source")
+	}
+
+	destTeams, err := discoverDestTeams(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list teams from destination")
+	}
+
+	destMemberships, err := discoverDestOrgMemberships(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Organization memberships from destination")
+	}
+
+	// Index the destination memberships by email
+	destMembershipsByEmail := make(map[string]*tfe.OrganizationMembership)
+	for _, m := range destMemberships {
+		if email := getMembershipEmail(m); email != "" {
+			destMembershipsByEmail[strings.ToLower(email)] = m
+		}
+	}
+
+	var unresolved []interface{}
+	invited := 0
+	added := 0
+
+	for _, srcteam := range srcTeams {
+		destteam, exists := getTeamByName(srcteam.Name, destTeams)
+		if !exists {
+			o.AddMessageUserProvided("Team does not exist in destination, run `tfm copy teams` first. Skipping members of:", srcteam.Name)
+			unresolved = append(unresolved, fmt.Sprintf("team %v does not exist in destination", srcteam.Name))
+			continue
+		}
+
+		srcMembers, err := c.SourceClient.TeamMembers.ListOrganizationMemberships(c.SourceContext, srcteam.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to list members of source team "+srcteam.Name)
+		}
+
+		if len(srcMembers) == 0 {
+			o.AddMessageUserProvided("No members found on source team:", srcteam.Name)
+			continue
+		}
+
+		destMembers, err := c.DestinationClient.TeamMembers.ListOrganizationMemberships(c.DestinationContext, destteam.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to list members of destination team "+destteam.Name)
+		}
+
+		destMemberIDs := make(map[string]bool, len(destMembers))
+		for _, m := range destMembers {
+			destMemberIDs[m.ID] = true
+		}
+
+		o.AddFormattedMessageUserProvided2("Copying %v members of team %v", len(srcMembers), srcteam.Name)
+
+		var toAdd []string
+
+		for _, srcmember := range srcMembers {
+			srcEmail := getMembershipEmail(srcmember)
+			if srcEmail == "" {
+				unresolved = append(unresolved, fmt.Sprintf("membership %v of team %v has no email", srcmember.ID, srcteam.Name))
+				continue
+			}
+
+			destEmail := srcEmail
+			if mapped, ok := usersMap[strings.ToLower(srcEmail)]; ok {
+				destEmail = mapped
+			}
+
+			destmember, isMember := destMembershipsByEmail[strings.ToLower(destEmail)]
+
+			// Invite the user to the destination org and the team in one request
+			if !isMember {
+				o.AddMessageUserProvided2(destEmail, "is not a member of the destination org, inviting to team", destteam.Name)
+				destmember, err = c.DestinationClient.OrganizationMemberships.Create(c.DestinationContext, c.DestinationOrganizationName, tfe.OrganizationMembershipCreateOptions{
+					Type:  "",
+					Email: &destEmail,
+					Teams: []*tfe.Team{{ID: destteam.ID}},
+				})
+				if err != nil {
+					unresolved = append(unresolved, fmt.Sprintf("%v (team %v): %v", srcEmail, srcteam.Name, err.Error()))
+					continue
+				}
+
+				destMembershipsByEmail[strings.ToLower(destEmail)] = destmember
+				destMemberIDs[destmember.ID] = true
+				invited++
+				continue
+			}
+
+			if destMemberIDs[destmember.ID] {
+				o.AddMessageUserProvided2(destEmail, "is already a member of destination team", destteam.Name)
+				continue
+			}
+
+			toAdd = append(toAdd, destmember.ID)
+			destMemberIDs[destmember.ID] = true
+		}
+
+		if len(toAdd) > 0 {
+			o.AddFormattedMessageUserProvided2("Adding %v existing Organization members to destination team %v", len(toAdd), destteam.Name)
+			err := c.DestinationClient.TeamMembers.Add(c.DestinationContext, destteam.ID, tfe.TeamMemberAddOptions{
+				OrganizationMembershipIDs: toAdd,
+			})
+			if err != nil {
+				unresolved = append(unresolved, fmt.Sprintf("%v members of team %v: %v", len(toAdd), destteam.Name, err.Error()))
+				continue
+			}
+			added += len(toAdd)
+		}
+	}
+
+	o.AddDeferredMessageRead("Users invited to destination org", invited)
+	o.AddDeferredMessageRead("Existing members added to teams", added)
+
+	if len(unresolved) > 0 {
+		o.AddDeferredListMessageRead("Unresolved team members", unresolved)
+	}
+
+	return nil
+}
@@ -16,7 +16,8 @@ import (
 )
 
 var (
-	o output.Output
+	o       output.Output
+	members bool
 
 	// `tfemig copy teams` command
 	teamCopyCmd = &cobra.Command{
@@ -24,6 +25,10 @@ var (
 		Short: "Copy Teams",
 		Long:  "Copy Teams from source to destination org",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if members {
+				return copyTeamMembers(tfclient.GetClientContexts())
+			}
+
 			return copyTeams(
 				tfclient.GetClientContexts())
 
@@ -35,6 +40,7 @@ var (
 )
 
 func init() {
+	teamCopyCmd.Flags().BoolVarP(&members, "members", "", false, "Copy team members and invite missing users to the destination org")
 
 	// Add commands
 	CopyCmd.AddCommand(teamCopyCmd)
@@ -0,0 +1,239 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"fmt"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+)
+
+// All functions related to copying variable set ownership and workspace/project assignments
+
+// Source and destination workspaces and projects used to translate variable set
+// parents and assignments between the source and destination orgs.
+type varSetScope struct {
+	srcWorkspaces  []*tfe.Workspace
+	destWorkspaces []*tfe.Workspace
+	srcProjects    []*tfe.Project
+	destProjects   []*tfe.Project
+	wsMapCfg       map[string]string
+	projMapCfg     map[string]string
+	unmatched      []interface{}
+}
+
+// Gathers the workspaces, projects and maps needed to translate variable set scopes
+func getVarSetScope(c tfclient.ClientContexts) (*varSetScope, error) {
+	var err error
+	scope := &varSetScope{}
+
+	scope.srcWorkspaces, err = discoverSrcWorkspaces(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list Workspaces from source")
+	}
+
+	scope.destWorkspaces, err = discoverDestWorkspaces(c, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list Workspaces from destination")
+	}
+
+	scope.srcProjects, err = listSrcProjects(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list Projects from source")
+	}
+
+	scope.destProjects, err = listDestProjects(c, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list Projects from destination")
+	}
+
+	scope.wsMapCfg, err = helper.ViperStringSliceMap("workspaces-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for workspaces-map")
+	}
+
+	scope.projMapCfg, err = helper.ViperStringSliceMap("projects-map")
+	if err != nil {
+		o.AddErrorUserProvided("Invalid input for projects-map")
+	}
+
+	return scope, nil
+}
+
+// Translates a source workspace ID to the matching destination workspace ID
+func (s *varSetScope) destWorkspaceID(srcWorkspaceID string) (string, string, bool) {
+	srcName := ""
+	for _, ws := range s.srcWorkspaces {
+		if ws.ID == srcWorkspaceID {
+			srcName = ws.Name
+			break
+		}
+	}
+	if srcName == "" {
+		return srcWorkspaceID, "", false
+	}
+
+	destName := srcName
+	if mapped, ok := s.wsMapCfg[srcName]; ok {
+		destName = mapped
+	}
+
+	for _, ws := range s.destWorkspaces {
+		if ws.Name == destName {
+			return destName, ws.ID, true
+		}
+	}
+	return destName, "", false
+}
+
+// Translates a source project ID to the matching destination project ID
+func (s *varSetScope) destProjectID(srcProjectID string) (string, string, bool) {
+	srcName := ""
+	for _, p := range s.srcProjects {
+		if p.ID == srcProjectID {
+			srcName = p.Name
+			break
+		}
+	}
+	if srcName == "" {
+		return srcProjectID, "", false
+	}
+
+	destName := srcName
+	if mapped, ok := s.projMapCfg[srcName]; ok {
+		destName = mapped
+	}
+
+	if p, exists := getProjectByName(destName, s.destProjects); exists {
+		return destName, p.ID, true
+	}
+	return destName, "", false
+}
+
+// Returns the destination parent of a variable set. Project owned variable sets are
+// owned by the matching destination project, all others by the destination org.
+func (s *varSetScope) destParent(variableSet *tfe.VariableSet) *tfe.Parent {
+	if variableSet.Parent == nil || variableSet.Parent.Project == nil {
+		return nil
+	}
+
+	destName, destID, ok := s.destProjectID(variableSet.Parent.Project.ID)
+	if !ok {
+		o.AddFormattedMessageUserProvided2("Owning project %v of variable set %v does not exist in destination. The variable set will be owned by the destination org.", destName, variableSet.Name)
+		s.unmatched = append(s.unmatched, fmt.Sprintf("variable set %v owning project %v", variableSet.Name, destName))
+		return nil
+	}
+
+	return &tfe.Parent{Project: &tfe.Project{ID: destID}}
+}
+
+// Applies the source variable set workspace and project assignments to the destination variable set.
+// Assignments that already exist in the destination are left as they are.
+func (s *varSetScope) copyAssignments(c tfclient.ClientContexts, srcSet *tfe.VariableSet, destSet *tfe.VariableSet) error {
+	if srcSet.Global {
+		return nil
+	}
+
+	existingWs := make(map[string]bool, len(destSet.Workspaces))
+	for _, ws := range destSet.Workspaces {
+		existingWs[ws.ID] = true
+	}
+
+	existingProj := make(map[string]bool, len(destSet.Projects))
+	for _, p := range destSet.Projects {
+		existingProj[p.ID] = true
+	}
+
+	var workspaces []*tfe.Workspace
+	for _, ws := range srcSet.Workspaces {
+		destName, destID, ok := s.destWorkspaceID(ws.ID)
+		if !ok {
+			s.unmatched = append(s.unmatched, fmt.Sprintf("variable set %v workspace %v", srcSet.Name, destName))
+			continue
+		}
+		if !existingWs[destID] {
+			workspaces = append(workspaces, &tfe.Workspace{ID: destID})
+		}
+	}
+
+	var projects []*tfe.Project
+	for _, p := range srcSet.Projects {
+		destName, destID, ok := s.destProjectID(p.ID)
+		if !ok {
+			s.unmatched = append(s.unmatched, fmt.Sprintf("variable set %v project %v", srcSet.Name, destName))
+			continue
+		}
+		if !existingProj[destID] {
+			projects = append(projects, &tfe.Project{ID: destID})
+		}
+	}
+
+	if len(workspaces) > 0 {
+		o.AddFormattedMessageUserProvided2("Applying variable set %v to %v destination workspaces", destSet.Name, len(workspaces))
+		err := c.DestinationClient.VariableSets.ApplyToWorkspaces(c.DestinationContext, destSet.ID, &tfe.VariableSetApplyToWorkspacesOptions{
+			Workspaces: workspaces,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(projects) > 0 {
+		o.AddFormattedMessageUserProvided2("Applying variable set %v to %v destination projects", destSet.Name, len(projects))
+		err := c.DestinationClient.VariableSets.ApplyToProjects(c.DestinationContext, destSet.ID, tfe.VariableSetApplyToProjectsOptions{
+			Projects: projects,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Copies the workspace and project assignments for the variable sets. If varsets is empty
+// all source variable sets are used, otherwise only the ones in the `varsets-map`.
+func copyVarSetAssignments(c tfclient.ClientContexts, scope *varSetScope, varsets map[string]string) error {
+	srcVarSets, err := discoverSrcVariableSets(c, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to list variable sets from source")
+	}
+
+	destVarSets, err := discoverDestVariableSets(c, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to list variable sets from destination")
+	}
+
+	for _, set := range srcVarSets {
+		destsetname := set.Name
+
+		if len(varsets) > 0 {
+			mapped, ok := varsets[set.Name]
+			if !ok {
+				continue
+			}
+			destsetname = mapped
+		}
+
+		for _, destset := range destVarSets {
+			if destset.Name != destsetname {
+				continue
+			}
+
+			err := scope.copyAssignments(c, set, destset)
+			if err != nil {
+				return errors.Wrap(err, "failed to apply variable set "+destsetname+" assignments")
+			}
+		}
+	}
+
+	if len(scope.unmatched) > 0 {
+		o.AddDeferredListMessageRead("Unmatched variable set targets in destination", scope.unmatched)
+	}
+
+	return nil
+}
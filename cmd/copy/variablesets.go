@@ -47,6 +47,7 @@ var (
 )
 
 func init() {
+	varSetCopyCmd.Flags().StringVarP(&secretsProvider, "secrets-provider", "", "", "Fill in sensitive variable values from a secrets provider: file, env or command")
 
 	// Add commands
 	CopyCmd.AddCommand(varSetCopyCmd)
@@ -69,6 +70,7 @@ func discoverSrcVariableSets(c tfclient.ClientContexts, output bool) ([]*tfe.Var
 			PageNumber: 1,
 			PageSize:   100,
 		},
+		Include: fmt.Sprintf("%s,%s", tfe.VariableSetWorkspaces, tfe.VariableSetProjects),
 	}
 
 	for {
@@ -100,6 +102,7 @@ func discoverDestVariableSets(c tfclient.ClientContexts, output bool) ([]*tfe.Va
 			PageNumber: 1,
 			PageSize:   100,
 		},
+		Include: fmt.Sprintf("%s,%s", tfe.VariableSetWorkspaces, tfe.VariableSetProjects),
 	}
 
 	for {
@@ -122,8 +125,9 @@ func discoverDestVariableSets(c tfclient.ClientContexts, output bool) ([]*tfe.Va
 	return varSets, nil
 }
 
-// Function that creates variable sets
-func createVariableSets(c tfclient.ClientContexts, variableSet *tfe.VariableSet, destSetNameCfg string, useCfg bool) (string, error) {
+// Function that creates variable sets. The priority and owning project of the source
+// variable set are kept, project owned sets are created in the matching destination project.
+func createVariableSets(c tfclient.ClientContexts, variableSet *tfe.VariableSet, destSetNameCfg string, useCfg bool, scope *varSetScope) (string, error) {
 
 	// If no config file `varsets-map` is specified
 	if !useCfg {
@@ -135,6 +139,8 @@ func createVariableSets(c tfclient.ClientContexts, variableSet *tfe.VariableSet,
 			Name:        &variableSet.Name,
 			Description: &variableSet.Description,
 			Global:      &variableSet.Global,
+			Priority:    &variableSet.Priority,
+			Parent:      scope.destParent(variableSet),
 		})
 
 		if err != nil {
@@ -159,6 +165,8 @@ func createVariableSets(c tfclient.ClientContexts, variableSet *tfe.VariableSet,
 			Name:        &destSetNameCfg,
 			Description: &variableSet.Description,
 			Global:      &variableSet.Global,
+			Priority:    &variableSet.Priority,
+			Parent:      scope.destParent(variableSet),
 		})
 
 		if err != nil {
@@ -271,14 +279,20 @@ func discoverDestVariableSetVariables(c tfclient.ClientContexts, destVarSetID st
 }
 
 // Function that creates variables in the destination target variable set.
-func createVariableSetVars(c tfclient.ClientContexts, destVarSetID string, destVarSetName string, variables *tfe.VariableSetVariable) error {
+// Sensitive values are looked up by the source variable set name and variable key.
+func createVariableSetVars(c tfclient.ClientContexts, destVarSetID string, destVarSetName string, srcVarSetName string, variables *tfe.VariableSetVariable, secrets *secretLookup) error {
 	o.AddFormattedMessageUserProvided2("Copying variable %v for variable set %v", variables.Key, destVarSetName)
 
+	value := variables.Value
+	if variables.Sensitive {
+		secrets.fill(srcVarSetName, variables.Key, &value)
+	}
+
 	//Create the variables in the variable set
 	vars, err := c.DestinationClient.VariableSetVariables.Create(c.DestinationContext, destVarSetID, &tfe.VariableSetVariableCreateOptions{
 		Type:        "",
 		Key:         &variables.Key,
-		Value:       &variables.Value,
+		Value:       &value,
 		Description: &variables.Description,
 		Category:    &variables.Category,
 		HCL:         &variables.HCL,
@@ -335,6 +349,12 @@ func copyVariableSetsAll(c tfclient.ClientContexts) error {
 		return errors.Wrap(err, "failed to list variable sets from destination")
 	}
 
+	// Get the workspaces and projects to translate variable set owners and assignments
+	scope, err := getVarSetScope(c)
+	if err != nil {
+		return err
+	}
+
 	// for each variable set in source
 	for _, set := range srcVarSets {
 
@@ -354,7 +374,7 @@ func copyVariableSetsAll(c tfclient.ClientContexts) error {
 		} else {
 
 			// Create a copy of the variable set in the destination
-			srcVarSetName, err := createVariableSets(c, set, "", false)
+			srcVarSetName, err := createVariableSets(c, set, "", false, scope)
 			if err != nil {
 				return errors.Wrap(err, "Failed to create variable sets in the destination org.")
 			}
@@ -364,13 +384,20 @@ func copyVariableSetsAll(c tfclient.ClientContexts) error {
 	}
 
 	// Copy all of the variables for the variable sets
-	copyVarSetVars(c)
+	secrets, err := newSecretLookup(secretsProvider)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create the secrets provider")
+	}
 
-	return nil
+	copyVarSetVars(c, secrets)
+	secrets.report()
+
+	// Apply the variable sets to the matching destination workspaces and projects
+	return copyVarSetAssignments(c, scope, nil)
 }
 
 // Copys the variable set variables for all variable sets
-func copyVarSetVars(c tfclient.ClientContexts) error {
+func copyVarSetVars(c tfclient.ClientContexts, secrets *secretLookup) error {
 	srcVarSets, err := discoverSrcVariableSets(c, false)
 	if err != nil {
 		return errors.Wrap(err, "failed to list variable sets from source")
@@ -408,7 +435,7 @@ func copyVarSetVars(c tfclient.ClientContexts) error {
 					o.AddFormattedMessageUserProvided("Variable named %v exists in destination variable set. Skipping.", variable.Key)
 
 				} else {
-					createVariableSetVars(c, destVarSetID, set.Name, variable)
+					err := createVariableSetVars(c, destVarSetID, set.Name, set.Name, variable, secrets)
 					if err != nil {
 						return errors.Wrap(err, "Failed to create variable in variable destination set.")
 					}
@@ -434,6 +461,12 @@ func copyVariableSetsCfg(c tfclient.ClientContexts, varsets map[string]string) e
 		return errors.Wrap(err, "failed to list variable sets from destination")
 	}
 
+	// Get the workspaces and projects to translate variable set owners and assignments
+	scope, err := getVarSetScope(c)
+	if err != nil {
+		return err
+	}
+
 	// for each key:element pair in the varsets map, assign the key and element to vars for readability
 	for key, element := range varsets {
 		srcsetname := key
@@ -471,7 +504,7 @@ func copyVariableSetsCfg(c tfclient.ClientContexts, varsets map[string]string) e
 					if exists {
 						o.AddFormattedMessageUserProvided2("Variable set named %v exist in destination org: %v. Skipping creation.", set.Name, c.DestinationOrganizationName)
 					} else {
-						srcVarSetName, err := createVariableSets(c, set, destsetname, true)
+						srcVarSetName, err := createVariableSets(c, set, destsetname, true, scope)
 						if err != nil {
 							return errors.Wrap(err, "Failed to create variable sets in the destination org.")
 						}
@@ -482,13 +515,20 @@ func copyVariableSetsCfg(c tfclient.ClientContexts, varsets map[string]string) e
 			}
 		}
 	}
-	copyVarSetVarsCfg(c, varsets)
+	secrets, err := newSecretLookup(secretsProvider)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create the secrets provider")
+	}
 
-	return nil
+	copyVarSetVarsCfg(c, varsets, secrets)
+	secrets.report()
+
+	// Apply the variable sets to the matching destination workspaces and projects
+	return copyVarSetAssignments(c, scope, varsets)
 }
 
 // Only copy the variables for the variable sets defined by the user in the configuration.
-func copyVarSetVarsCfg(c tfclient.ClientContexts, varsets map[string]string) error {
+func copyVarSetVarsCfg(c tfclient.ClientContexts, varsets map[string]string, secrets *secretLookup) error {
 	srcVarSets, err := discoverSrcVariableSets(c, false)
 	if err != nil {
 		return errors.Wrap(err, "failed to list variable sets from source")
@@ -548,7 +588,7 @@ func copyVarSetVarsCfg(c tfclient.ClientContexts, varsets map[string]string) err
 								o.AddFormattedMessageUserProvided("Variable named %v exists in destination variable set. Skipping.", variable.Key)
 
 							} else {
-								createVariableSetVars(c, destVarSetID, destsetname, variable)
+								err := createVariableSetVars(c, destVarSetID, destsetname, set.Name, variable, secrets)
 								if err != nil {
 									return errors.Wrap(err, "Failed to create variable in destination variable set.")
 								}
@@ -0,0 +1,265 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+)
+
+// All functions related to copying workspace configuration versions
+
+// Get the uploaded configuration versions of a source workspace, newest first. Only uploaded
+// configuration versions can be downloaded. If NumberOfVersions is 0 all are returned.
+func discoverSrcConfigVersions(c tfclient.ClientContexts, workspaceID string, NumberOfVersions int) ([]*tfe.ConfigurationVersion, error) {
+	cvs := []*tfe.ConfigurationVersion{}
+
+	opts := tfe.ConfigurationVersionListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+		Include:     []tfe.ConfigVerIncludeOpt{tfe.ConfigVerIngressAttributes},
+	}
+	for {
+		items, err := c.SourceClient.ConfigurationVersions.List(c.SourceContext, workspaceID, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cv := range items.Items {
+			if cv.Status != tfe.ConfigurationUploaded {
+				continue
+			}
+			cvs = append(cvs, cv)
+
+			if NumberOfVersions > 0 && len(cvs) >= NumberOfVersions {
+				return cvs, nil
+			}
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return cvs, nil
+}
+
+// Get the uploaded configuration versions of a destination workspace, newest first. Only uploaded
+// configuration versions can be downloaded. If NumberOfVersions is 0 all are returned.
+func discoverDestConfigVersions(c tfclient.ClientContexts, workspaceID string, NumberOfVersions int) ([]*tfe.ConfigurationVersion, error) {
+	cvs := []*tfe.ConfigurationVersion{}
+
+	opts := tfe.ConfigurationVersionListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+		Include:     []tfe.ConfigVerIncludeOpt{tfe.ConfigVerIngressAttributes},
+	}
+	for {
+		items, err := c.DestinationClient.ConfigurationVersions.List(c.DestinationContext, workspaceID, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cv := range items.Items {
+			if cv.Status != tfe.ConfigurationUploaded {
+				continue
+			}
+			cvs = append(cvs, cv)
+
+			if NumberOfVersions > 0 && len(cvs) >= NumberOfVersions {
+				return cvs, nil
+			}
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return cvs, nil
+}
+
+// Returns the MD5 checksums of the NumberOfVersions latest configuration versions of a destination
+// workspace and the checksum of the latest one. Configuration versions that can not be downloaded
+// are left out.
+func destConfigVersionMD5s(c tfclient.ClientContexts, workspaceID string, NumberOfVersions int) (map[string]bool, string, error) {
+	destCVs, err := discoverDestConfigVersions(c, workspaceID, NumberOfVersions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	md5s := map[string]bool{}
+	latest := ""
+	for i, cv := range destCVs {
+		slug, err := c.DestinationClient.ConfigurationVersions.Download(c.DestinationContext, cv.ID)
+		if err != nil {
+			continue
+		}
+		sum := fmt.Sprintf("%x", md5.Sum(slug))
+		md5s[sum] = true
+		if i == 0 {
+			latest = sum
+		}
+	}
+
+	return md5s, latest, nil
+}
+
+// Describes the source ingress attributes, which can not be set through the API
+func describeIngressAttributes(cv *tfe.ConfigurationVersion) string {
+	if cv.IngressAttributes == nil || cv.IngressAttributes.CommitSHA == "" {
+		return ""
+	}
+
+	ia := cv.IngressAttributes
+	description := fmt.Sprintf("commit %v", ia.CommitSHA)
+	if ia.Branch != "" {
+		description += fmt.Sprintf(" on branch %v", ia.Branch)
+	}
+	if ia.Tag != "" {
+		description += fmt.Sprintf(" tag %v", ia.Tag)
+	}
+	if ia.CommitURL != "" {
+		description += fmt.Sprintf(" (%v)", ia.CommitURL)
+	}
+
+	return description
+}
+
+// Main function for the `--config-versions` flag
+// Downloads the latest, or the NumberOfVersions latest, configuration versions of each CLI/API driven
+// source workspace and uploads them as new configuration versions on the destination workspace.
+func copyConfigVersions(c tfclient.ClientContexts, NumberOfVersions int) error {
+
+	if NumberOfVersions == 0 {
+		NumberOfVersions = 1
+	}
+
+	// Get the source target workspaces
+	srcWorkspaces, err := getSrcWorkspacesCfg(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Workspaces from source")
+	}
+
+	// Get/Check if Workspace map exists
+	wsMapCfg, err := helper.ViperStringSliceMap("workspaces-map")
+	if err != nil {
+		fmt.Println("invalid input for workspaces-map")
+	}
+
+	// Get the destination target workspaces
+	destWorkspaces, err := discoverDestWorkspaces(tfclient.GetClientContexts(), true)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Workspaces from destination")
+	}
+
+	var ingressNotes []interface{}
+
+	for _, srcworkspace := range srcWorkspaces {
+		destWorkSpaceName := srcworkspace.Name
+
+		// Check if the destination Workspace name differs from the source name
+		if len(wsMapCfg) > 0 {
+			destWorkSpaceName = wsMapCfg[srcworkspace.Name]
+		}
+
+		// VCS driven workspaces get their configuration from the VCS connection
+		if srcworkspace.VCSRepo != nil {
+			o.AddMessageUserProvided("Workspace is VCS driven, configuration versions will not be copied:", srcworkspace.Name)
+			continue
+		}
+
+		if !doesWorkspaceExist(destWorkSpaceName, destWorkspaces) {
+			fmt.Printf("Source workspace (%v) does not exist in destination (%v). No configuration versions to migrate\n", srcworkspace.Name, destWorkSpaceName)
+			continue
+		}
+
+		destWorkspaceId, err := getWorkspaceId(tfclient.GetClientContexts(), destWorkSpaceName)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get the ID of the destination Workspace that matches the Name of the Source Workspace")
+		}
+
+		srcCVs, err := discoverSrcConfigVersions(c, srcworkspace.ID, NumberOfVersions)
+		if err != nil {
+			return errors.Wrap(err, "failed to list configuration versions for workspace from source")
+		}
+
+		if len(srcCVs) == 0 {
+			o.AddMessageUserProvided("No uploaded configuration versions found for source workspace:", srcworkspace.Name)
+			continue
+		}
+
+		// The destination configuration versions are compared so a rerun does not upload the same slugs again
+		destMD5s, latestDestMD5, err := destConfigVersionMD5s(c, destWorkspaceId, len(srcCVs))
+		if err != nil {
+			return errors.Wrap(err, "failed to list configuration versions for workspace from destination")
+		}
+
+		// The workspace is up to date if the latest source configuration version is the latest in the destination
+		latestSlug, err := c.SourceClient.ConfigurationVersions.Download(c.SourceContext, srcCVs[0].ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to download configuration version "+srcCVs[0].ID)
+		}
+		if fmt.Sprintf("%x", md5.Sum(latestSlug)) == latestDestMD5 {
+			o.AddMessageUserProvided2(srcCVs[0].ID, "matches the latest configuration version of destination workspace, no configuration versions to migrate for", destWorkSpaceName)
+			continue
+		}
+
+		o.AddFormattedMessageCalculated2("Copying %d configuration versions of workspace %v", len(srcCVs), srcworkspace.Name)
+
+		// Upload oldest first so the latest source configuration version is the latest in the destination
+		for i := len(srcCVs) - 1; i >= 0; i-- {
+			srccv := srcCVs[i]
+
+			slug := latestSlug
+			if i > 0 {
+				slug, err = c.SourceClient.ConfigurationVersions.Download(c.SourceContext, srccv.ID)
+				if err != nil {
+					return errors.Wrap(err, "failed to download configuration version "+srccv.ID)
+				}
+
+				// Older configuration versions that already exist are not uploaded again, the latest one
+				// is always uploaded so it becomes the latest in the destination
+				if destMD5s[fmt.Sprintf("%x", md5.Sum(slug))] {
+					o.AddMessageUserProvided2(srccv.ID, "exists in destination workspace", destWorkSpaceName)
+					continue
+				}
+			}
+
+			destcv, err := c.DestinationClient.ConfigurationVersions.Create(c.DestinationContext, destWorkspaceId, tfe.ConfigurationVersionCreateOptions{
+				Type:          "",
+				AutoQueueRuns: tfe.Bool(false),
+				Speculative:   tfe.Bool(srccv.Speculative),
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to create configuration version in destination workspace "+destWorkSpaceName)
+			}
+
+			err = c.DestinationClient.ConfigurationVersions.UploadTarGzip(c.DestinationContext, destcv.UploadURL, bytes.NewReader(slug))
+			if err != nil {
+				return errors.Wrap(err, "failed to upload configuration version to destination workspace "+destWorkSpaceName)
+			}
+
+			fmt.Printf("Migrated configuration version %v to %v for workspace Src: %v Dst: %v\n", srccv.ID, destcv.ID, srcworkspace.Name, destWorkSpaceName)
+
+			if ingress := describeIngressAttributes(srccv); ingress != "" {
+				ingressNotes = append(ingressNotes, fmt.Sprintf("%v %v: %v", destWorkSpaceName, destcv.ID, ingress))
+			}
+		}
+
+		o.AddDeferredMessageRead("Copied configuration versions for", destWorkSpaceName)
+	}
+
+	if len(ingressNotes) > 0 {
+		o.AddDeferredListMessageRead("Ingress attributes can not be set through the API, source values", ingressNotes)
+	}
+
+	return nil
+}
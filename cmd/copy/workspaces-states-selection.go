@@ -0,0 +1,218 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/spf13/viper"
+)
+
+// All functions related to selecting which source state versions are copied
+
+var (
+	stateSince       string
+	stateUntil       string
+	stateSerials     string
+	stateOnlyCurrent bool
+)
+
+// A range of state serials, both ends included
+type serialRange struct {
+	from int64
+	to   int64
+}
+
+// The state versions of a workspace to copy
+type stateSelection struct {
+	since       time.Time
+	until       time.Time
+	serials     []serialRange
+	onlyCurrent bool
+}
+
+// Parses a date as 2006-01-02 or RFC3339. A date without a time is the start of the day,
+// or the end of the day when endOfDay is set.
+func parseStateDate(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return t, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// Parses serials such as 10-25 or 3,7,10-25
+func parseSerialRanges(value string) ([]serialRange, error) {
+	ranges := []serialRange{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid serials %q, expected a list of serials or ranges such as 3,10-25", value)
+		}
+		to := from
+		if len(bounds) == 2 {
+			to, err = strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 64)
+			if err != nil || to < from {
+				return nil, fmt.Errorf("invalid serials %q, expected a list of serials or ranges such as 3,10-25", value)
+			}
+		}
+		ranges = append(ranges, serialRange{from: from, to: to})
+	}
+	return ranges, nil
+}
+
+// Sets a selection option from the flags or the config file
+func (s *stateSelection) set(option string, value string) error {
+	var err error
+	switch option {
+	case "since":
+		s.since, err = parseStateDate(value, false)
+	case "until":
+		s.until, err = parseStateDate(value, true)
+	case "serials":
+		s.serials, err = parseSerialRanges(value)
+	default:
+		err = fmt.Errorf("unknown state selection option %q", option)
+	}
+	return err
+}
+
+// Returns true if no selection options are set
+func (s *stateSelection) empty() bool {
+	return s.since.IsZero() && s.until.IsZero() && len(s.serials) == 0 && !s.onlyCurrent
+}
+
+// Describes the selection options
+func (s *stateSelection) String() string {
+	options := []string{}
+	if !s.since.IsZero() {
+		options = append(options, "since "+s.since.Format(time.RFC3339))
+	}
+	if !s.until.IsZero() {
+		options = append(options, "until "+s.until.Format(time.RFC3339))
+	}
+	if len(s.serials) > 0 {
+		serials := []string{}
+		for _, r := range s.serials {
+			if r.from == r.to {
+				serials = append(serials, fmt.Sprint(r.from))
+			} else {
+				serials = append(serials, fmt.Sprintf("%d-%d", r.from, r.to))
+			}
+		}
+		options = append(options, "serials "+strings.Join(serials, ","))
+	}
+	if s.onlyCurrent {
+		options = append(options, "only the current state")
+	}
+	return strings.Join(options, ", ")
+}
+
+// Returns true if a state version matches the since, until and serials options
+func (s *stateSelection) matches(sv *tfe.StateVersion) bool {
+	if !s.since.IsZero() && sv.CreatedAt.Before(s.since) {
+		return false
+	}
+	if !s.until.IsZero() && sv.CreatedAt.After(s.until) {
+		return false
+	}
+	if len(s.serials) == 0 {
+		return true
+	}
+	for _, r := range s.serials {
+		if sv.Serial >= r.from && sv.Serial <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the selected state versions from a newest first list of state versions. With
+// only-current only the newest state version is selected, if it matches the other options.
+func (s *stateSelection) filter(states []*tfe.StateVersion) []*tfe.StateVersion {
+	if s.onlyCurrent && len(states) > 0 {
+		states = states[:1]
+	}
+
+	selected := []*tfe.StateVersion{}
+	for _, sv := range states {
+		if s.matches(sv) {
+			selected = append(selected, sv)
+		}
+	}
+	return selected
+}
+
+// Gets the state selection of each source workspace. The `--since`, `--until`, `--serials` and
+// `--only-current` flags apply to every workspace, the `state-since`, `state-until`, `state-serials`
+// and `state-only-current` config file maps override them for a single workspace.
+func getStateSelections() (*stateSelection, map[string]*stateSelection, error) {
+	defaults := &stateSelection{}
+	for option, value := range map[string]string{"since": stateSince, "until": stateUntil, "serials": stateSerials} {
+		if value == "" {
+			continue
+		}
+		if err := defaults.set(option, value); err != nil {
+			return nil, nil, err
+		}
+	}
+	defaults.onlyCurrent = stateOnlyCurrent
+
+	workspaces := map[string]*stateSelection{}
+	for _, option := range []string{"since", "until", "serials"} {
+		cfg, err := helper.ViperStringSliceMap("state-" + option)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid input for state-%v: %v", option, err)
+		}
+		for ws, value := range cfg {
+			if _, ok := workspaces[ws]; !ok {
+				selection := *defaults
+				workspaces[ws] = &selection
+			}
+			if err := workspaces[ws].set(option, value); err != nil {
+				return nil, nil, fmt.Errorf("invalid state-%v for workspace %v: %v", option, ws, err)
+			}
+		}
+	}
+
+	for _, ws := range viper.GetStringSlice("state-only-current") {
+		if _, ok := workspaces[ws]; !ok {
+			selection := *defaults
+			workspaces[ws] = &selection
+		}
+		workspaces[ws].onlyCurrent = true
+	}
+
+	return defaults, workspaces, nil
+}
+
+// Shows the selected state versions of a workspace before they are uploaded
+func showStateSelection(workspaceName string, selection *stateSelection, found int, selected []*tfe.StateVersion) {
+	if selection.empty() {
+		return
+	}
+
+	fmt.Printf("Selected %d of %d state versions for workspace %v (%v)\n", len(selected), found, workspaceName, selection)
+	for i := len(selected) - 1; i >= 0; i-- {
+		fmt.Printf("  serial %v created %v\n", selected[i].Serial, selected[i].CreatedAt.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+func TestParseSerialRanges(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []serialRange
+		err      bool
+	}{
+		{input: "10-25", expected: []serialRange{{from: 10, to: 25}}},
+		{input: "3, 7,10-25", expected: []serialRange{{from: 3, to: 3}, {from: 7, to: 7}, {from: 10, to: 25}}},
+		{input: "5,", expected: []serialRange{{from: 5, to: 5}}},
+		{input: "25-10", err: true},
+		{input: "a-3", err: true},
+		{input: "3-b", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			ranges, err := parseSerialRanges(tc.input)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", ranges)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ranges, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, ranges)
+			}
+		})
+	}
+}
+
+func TestStateSelectionFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+
+	// Newest first, as the state versions are listed
+	states := []*tfe.StateVersion{}
+	for serial := 5; serial >= 1; serial-- {
+		states = append(states, &tfe.StateVersion{Serial: int64(serial), CreatedAt: day(serial)})
+	}
+
+	cases := []struct {
+		name     string
+		options  map[string]string
+		current  bool
+		expected []int64
+	}{
+		{name: "none", expected: []int64{5, 4, 3, 2, 1}},
+		{name: "serials", options: map[string]string{"serials": "1,3-4"}, expected: []int64{4, 3, 1}},
+		{name: "since", options: map[string]string{"since": "2024-01-04"}, expected: []int64{5, 4}},
+		{name: "until", options: map[string]string{"until": "2024-01-02"}, expected: []int64{2, 1}},
+		{name: "since and serials", options: map[string]string{"since": "2024-01-03", "serials": "1-3"}, expected: []int64{3}},
+		{name: "only current", current: true, expected: []int64{5}},
+		{name: "only current not selected", options: map[string]string{"serials": "1-4"}, current: true, expected: []int64{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &stateSelection{onlyCurrent: tc.current}
+			for option, value := range tc.options {
+				if err := s.set(option, value); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			serials := []int64{}
+			for _, sv := range s.filter(states) {
+				serials = append(serials, sv.Serial)
+			}
+			if !reflect.DeepEqual(serials, tc.expected) {
+				t.Errorf("expected serials %v, got %v", tc.expected, serials)
+			}
+		})
+	}
+}
@@ -4,20 +4,18 @@
 package copy
 
 import (
-	"crypto/md5"
-	b64 "encoding/base64"
 	"fmt"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/hashicorp-services/tfm/cmd/helper"
 	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
 	tfe "github.com/hashicorp/go-tfe"
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 )
 
 // 1. Get source state versions per workspace and reverse the slice
@@ -26,10 +24,10 @@ import (
 // 4. Get the WS ID of the WS Name to copy state too
 // 5. Get the download URL of the source state
 // 6. Download the State into memory
-// 7. Create MD5 checksum
-// 8. Incremenet the serial #
-// 9. Lock the workspace if not locked
-// 10. Use the StateVersions.Create to upload state to destination
+// 7. Parse the state and create MD5 checksum
+// 8. Lock the workspace if not locked
+// 9. Use the StateVersions.Create to upload state to destination
+// 10. Download the uploaded state and compare the MD5 checksum
 // 11. Unlock the workspace if locked
 
 // Iterate backwards through the srcstate slice and append each element to a new slice
@@ -76,8 +74,8 @@ func reverseSlice(input []*tfe.StateVersion) []*tfe.StateVersion {
 	return output
 }
 
-// Get the source workspace state files from the provided workspace
-func discoverSrcStates(c tfclient.ClientContexts, ws string, NumberOfStates int) ([]*tfe.StateVersion, error) {
+// Get the source workspace state files from the provided workspace that match the state selection
+func discoverSrcStates(c tfclient.ClientContexts, ws string, NumberOfStates int, selection *stateSelection) ([]*tfe.StateVersion, error) {
 	o.AddMessageUserProvided("Getting list of states from source workspace ", ws)
 	srcStates := []*tfe.StateVersion{}
 
@@ -102,6 +100,10 @@ func discoverSrcStates(c tfclient.ClientContexts, ws string, NumberOfStates int)
 	}
 	o.AddFormattedMessageCalculated("Found %d Workspace states", len(srcStates))
 
+	// Select the state versions with the `--since`, `--until`, `--serials` and `--only-current` options
+	found := len(srcStates)
+	srcStates = selection.filter(srcStates)
+
 	if NumberOfStates != 0 {
 		o.AddFormattedMessageCalculated("Only the %d newest workspace states will be migrated", NumberOfStates)
 
@@ -115,6 +117,8 @@ func discoverSrcStates(c tfclient.ClientContexts, ws string, NumberOfStates int)
 		srcStates = srcStates[:len(srcStates)-(len(srcStates)-NumberOfStates)]
 	}
 
+	showStateSelection(ws, selection, found, srcStates)
+
 	return srcStates, nil
 }
 
@@ -148,14 +152,44 @@ func discoverDestStates(c tfclient.ClientContexts, ws string) ([]*tfe.StateVersi
 	return destStates, nil
 }
 
-// Check the existence of the state in the destination using the unique serial number
-func doesStateExist(stateSerial int64, s []*tfe.StateVersion) bool {
-	for _, state := range s {
-		if stateSerial == state.Serial {
-			return true
+// Check the existence of the state in the destination using its serial. Transformed states are uploaded
+// with the serial of the next source state, so a destination state with the same serial may be another
+// state. Only then, when the serial is the serial of the current destination state or of more than one
+// destination state, the MD5 checksums are compared. Returns true as collides if a destination state has
+// the serial but another checksum, the source state then has to be forced on top of it.
+func doesStateExist(c tfclient.ClientContexts, srcstate *tfe.StateVersion, s []*tfe.StateVersion) (exists bool, collides bool, err error) {
+	// The destination states are listed newest first
+	matches := []*tfe.StateVersion{}
+	suspected := false
+	for i, state := range s {
+		if srcstate.Serial != state.Serial {
+			continue
 		}
+		matches = append(matches, state)
+		suspected = suspected || i == 0
+	}
+	if len(matches) == 0 {
+		return false, false, nil
+	}
+	if len(matches) == 1 && !suspected {
+		return true, false, nil
+	}
+
+	// Both states are streamed to compute their checksums, the states are not kept
+	srcMD5, err := tfstate.DownloadMD5(c.SourceContext, c.SourceClient, srcstate.DownloadURL)
+	if err != nil {
+		return false, false, errors.Wrapf(err, "failed to download source state serial %v", srcstate.Serial)
 	}
-	return false
+	for _, state := range matches {
+		destMD5, err := tfstate.DownloadMD5(c.DestinationContext, c.DestinationClient, state.DownloadURL)
+		if err != nil {
+			return false, false, errors.Wrapf(err, "failed to download destination state serial %v", state.Serial)
+		}
+		if srcMD5 == destMD5 {
+			return true, false, nil
+		}
+	}
+	return false, true, nil
 }
 
 // Finds the destination Workspace ID of the workspace with a matching name as a workspace in the source
@@ -169,15 +203,9 @@ func getWorkspaceId(c tfclient.ClientContexts, ws string) (string, error) {
 	return w.ID, nil
 }
 
-func downloadSourceState(c tfclient.ClientContexts, downloadUrl string) ([]byte, error) {
-
-	// Takes download URL from StateVersions.List function and stores state as a []byte type
-	state, err := c.SourceClient.StateVersions.Download(c.SourceContext, downloadUrl)
-	if err != nil {
-		return state, err
-	}
-
-	return state, nil
+// Takes download URL from StateVersions.List function and streams the state to a temp file
+func downloadSourceState(c tfclient.ClientContexts, downloadUrl string) (*tfstate.File, error) {
+	return tfstate.Download(c.SourceContext, c.SourceClient, downloadUrl)
 }
 
 // Locks the workspace provided
@@ -219,25 +247,419 @@ func unlockWorkspace(c tfclient.ClientContexts, destWorkspaceId string) error {
 	return nil
 }
 
-// Main function for `--state` flag
-func copyStates(c tfclient.ClientContexts, NumberOfStates int) error {
+// Locks a source workspace while its states are copied. Returns true if tfm locked the workspace.
+// A workspace that can not be locked, for example because the token can not lock it, is copied
+// without the lock.
+func lockSrcWorkspace(c tfclient.ClientContexts, srcworkspace *tfe.Workspace) bool {
+	if srcworkspace.Locked {
+		return false
+	}
 
-	// Get the source target workspaces
-	srcWorkspaces, err := getSrcWorkspacesCfg(c)
+	message := "tfm state copy"
+	_, err := c.SourceClient.Workspaces.Lock(c.SourceContext, srcworkspace.ID, tfe.WorkspaceLockOptions{
+		Reason: &message,
+	})
 	if err != nil {
-		return errors.Wrap(err, "failed to list Workspaces from source")
+		fmt.Printf("Unable to lock source workspace %v, runs can start while its states are copied: %v\n", srcworkspace.Name, err)
+		return false
+	}
+
+	fmt.Println("Locking source Workspace:", srcworkspace.Name)
+	return true
+}
+
+// Unlocks a source workspace if it was locked by tfm for the state copy
+func unlockSrcWorkspace(c tfclient.ClientContexts, srcworkspace *tfe.Workspace, locked bool) {
+	if !locked {
+		return
+	}
+
+	if _, err := c.SourceClient.Workspaces.Unlock(c.SourceContext, srcworkspace.ID); err != nil {
+		o.AddErrorUserProvided2("Unable to unlock source workspace "+srcworkspace.Name+":", err.Error())
+		return
+	}
+	fmt.Println("Unlocking source Workspace:", srcworkspace.Name)
+}
+
+// Reads back an uploaded state version and compares its MD5 checksum with the uploaded state
+func verifyStateUpload(c tfclient.ClientContexts, deststate *tfe.StateVersion, expectedMD5 string) error {
+	return tfstate.Verify(c.DestinationContext, c.DestinationClient, deststate.ID, expectedMD5)
+}
+
+// Returns the JSON state and JSON outputs of a state file. The JSON state is downloaded from the
+// source JSON download URL when there is one, otherwise it is generated from the state file unless
+// the state is larger than tfstate.MaxJSONStateSize. If neither works the state is uploaded without
+// it and the destination populates it on the next run.
+func getJSONState(c tfclient.ClientContexts, jsonDownloadURL string, state *tfstate.File, parsedState *tfstate.State) (*tfstate.File, []byte) {
+	var jsonState *tfstate.File
+	if jsonDownloadURL != "" {
+		if f, err := tfstate.Download(c.SourceContext, c.SourceClient, jsonDownloadURL); err == nil {
+			jsonState = f
+		}
+	}
+
+	// Generating the JSON state needs the whole state in memory
+	if jsonState == nil && state.Size > tfstate.MaxJSONStateSize {
+		fmt.Printf("State serial %v is larger than %d MB, it is uploaded without a JSON state\n", parsedState.Serial, tfstate.MaxJSONStateSize>>20)
+	} else if jsonState == nil {
+		data, err := state.Read()
+		if err == nil {
+			data, err = tfstate.JSONState(data)
+		}
+		if err == nil {
+			jsonState, err = tfstate.NewFile(data)
+		}
+		if err != nil {
+			fmt.Printf("Unable to get the JSON state of state serial %v: %v\n", parsedState.Serial, err)
+		}
+	}
+
+	jsonOutputs, err := tfstate.JSONOutputs(parsedState)
+	if err != nil {
+		fmt.Printf("Unable to get the JSON outputs of state serial %v: %v\n", parsedState.Serial, err)
+	}
+
+	return jsonState, jsonOutputs
+}
+
+// Uploads a state file to a destination workspace as a new state version and verifies the upload.
+// Force is needed to upload a state with a different lineage than the current state.
+func createStateVersion(c tfclient.ClientContexts, destWorkspaceId string, state *tfstate.File, parsedState *tfstate.State, jsonState *tfstate.File, jsonOutputs []byte, force bool) error {
+	deststate, inline, err := tfstate.Upload(c.DestinationContext, c.DestinationClient, destWorkspaceId, tfstate.UploadOptions{
+		State:       state,
+		Parsed:      parsedState,
+		JSONState:   jsonState,
+		JSONOutputs: jsonOutputs,
+		Force:       force,
+	})
+	if err != nil {
+		return err
+	}
+
+	if inline {
+		fmt.Printf("Destination does not support state version uploads, state serial %v was sent inline\n", parsedState.Serial)
+	}
+
+	// Download the uploaded state and compare it to the source
+	return verifyStateUpload(c, deststate, state.MD5)
+}
+
+// The current state of a destination workspace that the copied states are uploaded on top of
+type destCurrent struct {
+	// The lineage of the current state, empty if the workspace has no state
+	lineage string
+	// The serial of the current state
+	serial int64
+	// Set by the state-regenerate-lineage transform. Source states replace a current state with a
+	// regenerated lineage instead of failing to upload.
+	replaceLineage bool
+}
+
+// Downloads a source state version, uploads it to the destination workspace and verifies the upload.
+// A state that collides with a destination state of the same serial, or that has another lineage than a
+// regenerated destination lineage, is forced. Returns the migrated state file, which the caller removes.
+func migrateState(c tfclient.ClientContexts, srcstate *tfe.StateVersion, destWorkspaceId string, destWorkSpaceName string, dest *destCurrent, collides bool) (*tfstate.File, error) {
+
+	// Download state from source
+	state, err := downloadSourceState(c, srcstate.DownloadURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download source state")
+	}
+
+	// Parse the state to get the serial and lineage
+	parsedState, err := parseStateFile(state)
+	if err != nil {
+		state.Remove()
+		return nil, errors.Wrapf(err, "state version %v", srcstate.ID)
+	}
+
+	force := false
+	if collides {
+		fmt.Printf("State serial %v replaces a state with the same serial and another checksum, such as a transformed state, in workspace %v\n", parsedState.Serial, destWorkSpaceName)
+		force = true
+	}
+	if dest.lineage != "" && parsedState.Lineage != dest.lineage {
+		if !dest.replaceLineage {
+			state.Remove()
+			return nil, fmt.Errorf("state serial %v has lineage %v but the current state of workspace %v has lineage %v, set state-regenerate-lineage to copy states to a workspace with a regenerated lineage",
+				parsedState.Serial, parsedState.Lineage, destWorkSpaceName, dest.lineage)
+		}
+		fmt.Printf("State serial %v replaces the regenerated lineage %v of workspace %v\n", parsedState.Serial, dest.lineage, destWorkSpaceName)
+		force = true
+	}
+
+	// Get the JSON state and outputs so the destination shows outputs before the next run
+	jsonState, jsonOutputs := getJSONState(c, srcstate.JSONDownloadURL, state, parsedState)
+	defer jsonState.Remove()
+
+	// Lock the destination workspace
+	lockWorkspace(c, destWorkspaceId)
+	fmt.Printf("Migrating state version %v serial %v (format %v, terraform %v, %d outputs, %d bytes) to workspace %v\n", srcstate.StateVersion, parsedState.Serial, parsedState.Version, parsedState.TerraformVersion, len(parsedState.Outputs), state.Size, destWorkSpaceName)
+
+	if err := createStateVersion(c, destWorkspaceId, state, parsedState, jsonState, jsonOutputs, force); err != nil {
+		state.Remove()
+		return nil, err
+	}
+	dest.lineage = parsedState.Lineage
+	dest.serial = parsedState.Serial
+
+	return state, nil
+}
+
+// Parses a state file without loading it into memory
+func parseStateFile(state *tfstate.File) (*tfstate.State, error) {
+	f, err := os.Open(state.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return tfstate.ParseReader(f)
+}
+
+// Applies the state transforms from the config file to the latest migrated state of a workspace
+// and uploads the transformed state as a new state version
+func transformState(c tfclient.ClientContexts, transforms tfstate.Transforms, state *tfstate.File, destWorkspaceId string, destWorkSpaceName string) error {
+	data, err := state.Read()
+	if err != nil {
+		return err
 	}
 
+	result, err := tfstate.Transform(data, transforms)
+	if err != nil {
+		return errors.Wrap(err, "failed to transform state")
+	}
+
+	if len(result.Changes) == 0 {
+		o.AddMessageUserProvided("No state transforms apply to the state of workspace", destWorkSpaceName)
+		return nil
+	}
+
+	fmt.Printf("Transforming state of workspace %v:\n%v", destWorkSpaceName, result.Diff())
+
+	transformed, err := tfstate.NewFile(result.State)
+	if err != nil {
+		return err
+	}
+	defer transformed.Remove()
+
+	jsonState, jsonOutputs := getJSONState(c, "", transformed, result.Parsed)
+	defer jsonState.Remove()
+
+	lockWorkspace(c, destWorkspaceId)
+	if err := createStateVersion(c, destWorkspaceId, transformed, result.Parsed, jsonState, jsonOutputs, transforms.RegenerateLineage); err != nil {
+		return errors.Wrap(err, "failed to upload transformed state")
+	}
+
+	o.AddDeferredMessageRead("Transformed state uploaded for", fmt.Sprintf("%v serial %v MD5 %v", destWorkSpaceName, result.Parsed.Serial, result.MD5))
+	return nil
+}
+
+// Backs up the current state of a destination workspace before states are copied to it.
+// The backup directory is created for the first workspace that has a current state.
+// Returns the backup entry of the current state, nil if the workspace has no state.
+func backupDestState(c tfclient.ClientContexts, backup *tfstate.Backup, destWorkspaceId string, destWorkSpaceName string) (*tfstate.Backup, *tfstate.ManifestEntry, error) {
+	current, err := c.DestinationClient.StateVersions.ReadCurrent(c.DestinationContext, destWorkspaceId)
+	if err == tfe.ErrResourceNotFound {
+		return backup, nil, nil
+	}
+	if err != nil {
+		return backup, nil, err
+	}
+
+	if backup == nil {
+		backup, err = tfstate.NewBackup(tfstate.BackupDir(""), "destination", c.DestinationHostname, c.DestinationOrganizationName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entry, err := backup.Add(c.DestinationContext, c.DestinationClient, &tfe.Workspace{ID: destWorkspaceId, Name: destWorkSpaceName}, current, true)
+	if err != nil {
+		return backup, nil, err
+	}
+
+	fmt.Printf("Backed up current state serial %v of destination workspace %v to %v\n", entry.Serial, destWorkSpaceName, backup.Dir)
+	return backup, entry, nil
+}
+
+// Writes a failed state migration to an error log file
+func logStateMigrationError(srcWorkspaceName string, migrationErr error) {
+	fmt.Println("failed to migrate state file. Moving onto next workspace.", migrationErr)
+
+	// Get the current timestamp and format it as a string
+	timestamp := time.Now().Format(time.RFC850)
+
+	// Replace colons with a different character to make the filename Windows-compatible
+	safeTimestamp := strings.ReplaceAll(timestamp, ":", "-")
+
+	// Create a file to store workspace names with errors
+	errorLogFileName := fmt.Sprintf("workspace_error_log_%s.txt", safeTimestamp)
+	errorLogFile, err := os.Create(errorLogFileName)
+	if err != nil {
+		fmt.Printf("Failed to create error log file: %v\n", err)
+		return
+	}
+	defer errorLogFile.Close()
+
+	errorLogFile.WriteString(fmt.Sprintf("Failed to migrate state file for source workspace: %v: %v\n", srcWorkspaceName, migrationErr))
+}
+
+// The state selection and transforms of a state copy and the failed state migrations of its workspaces
+type stateCopy struct {
+	numberOfStates   int
+	defaultSelection *stateSelection
+	wsSelections     map[string]*stateSelection
+	transforms       tfstate.Transforms
+	backup           *tfstate.Backup
+	failed           []interface{}
+	// Selected states older than the current destination state, which are not uploaded
+	older []interface{}
+}
+
+// Reads the state selections and transforms from the flags and config file
+func newStateCopy(NumberOfStates int) (*stateCopy, error) {
+	// Get the state versions to copy for each workspace
+	defaultSelection, wsSelections, err := getStateSelections()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid state selection")
+	}
+
+	// Get the state transforms to apply to the latest state of each workspace
+	transforms, err := tfstate.TransformsFromConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid state transforms")
+	}
+
+	return &stateCopy{
+		numberOfStates:   NumberOfStates,
+		defaultSelection: defaultSelection,
+		wsSelections:     wsSelections,
+		transforms:       transforms,
+	}, nil
+}
+
+// Records a failed state migration of a workspace
+func (sc *stateCopy) fail(srcWorkspaceName string, failure string, err error) {
+	logStateMigrationError(srcWorkspaceName, err)
+	sc.failed = append(sc.failed, fmt.Sprintf("%v %v: %v", srcWorkspaceName, failure, err))
+}
+
+// Copies the states of a source workspace that do not exist in the destination workspace and applies the
+// state transforms. Failed state migrations are recorded and stop the migration of the workspace. The
+// destination workspace is left locked if a state was copied.
+func (sc *stateCopy) copyWorkspace(c tfclient.ClientContexts, srcworkspace *tfe.Workspace, destWorkspaceId string, destWorkSpaceName string) error {
+	// Get the source workspace states
+	selection := sc.defaultSelection
+	if wsSelection, ok := sc.wsSelections[srcworkspace.Name]; ok {
+		selection = wsSelection
+	}
+	srcStates, err := discoverSrcStates(c, srcworkspace.Name, sc.numberOfStates, selection)
+	if err != nil {
+		return errors.Wrap(err, "failed to list state files for workspace from source")
+	}
+
+	// Get the destination workspace states
+	destStates, err := discoverDestStates(c, destWorkSpaceName)
+	if err != nil {
+		return errors.Wrap(err, "failed to list state files for workspace from destination")
+	}
+
+	// Find the source states that were not copied yet
+	missing := []*tfe.StateVersion{}
+	collisions := map[string]bool{}
+	for _, srcstate := range reverseSlice(srcStates) {
+		exists, collides, err := doesStateExist(c, srcstate, destStates)
+		if err != nil {
+			sc.fail(srcworkspace.Name, fmt.Sprintf("serial %v", srcstate.Serial), err)
+			return nil
+		}
+		if exists {
+			fmt.Printf("State Version %v with Serial %v exists in destination will not migrate\n", srcstate.StateVersion, srcstate.Serial)
+			continue
+		}
+		missing = append(missing, srcstate)
+		collisions[srcstate.ID] = collides
+	}
+
+	// Back up the current destination state before any state is copied to the workspace
+	dest := &destCurrent{replaceLineage: sc.transforms.RegenerateLineage}
+	if len(missing) > 0 {
+		var entry *tfstate.ManifestEntry
+		sc.backup, entry, err = backupDestState(c, sc.backup, destWorkspaceId, destWorkSpaceName)
+		if err != nil {
+			sc.fail(srcworkspace.Name, "backup", errors.Wrap(err, "failed to back up the current destination state"))
+			return nil
+		}
+		if entry != nil {
+			dest.lineage = entry.Lineage
+			dest.serial = entry.Serial
+		}
+	}
+
+	// Upload each missing state in order of its serial
+	var latestState *tfstate.File
+	defer func() { latestState.Remove() }()
+
+	for _, srcstate := range missing {
+		// A state with a lower serial than the current destination state would either fail to upload or
+		// replace the current state with an older one, for example when --serials selects an older range
+		if dest.lineage != "" && srcstate.Serial < dest.serial {
+			fmt.Printf("State serial %v is older than the current state serial %v of workspace %v and will not migrate\n", srcstate.Serial, dest.serial, destWorkSpaceName)
+			sc.older = append(sc.older, fmt.Sprintf("%v serial %v (destination %v serial %v)", srcworkspace.Name, srcstate.Serial, destWorkSpaceName, dest.serial))
+			continue
+		}
+
+		// Download, upload and verify the state. If there is an error output the error, log it, and move onto the next workspace.
+		state, err := migrateState(c, srcstate, destWorkspaceId, destWorkSpaceName, dest, collisions[srcstate.ID])
+		if err != nil {
+			sc.fail(srcworkspace.Name, fmt.Sprintf("serial %v", srcstate.Serial), err)
+			return nil
+		}
+
+		// Only the latest state is kept for the state transforms
+		latestState.Remove()
+		latestState = state
+	}
+
+	// Upload the transformed latest state on top of the migrated states
+	if !sc.transforms.Empty() && latestState != nil {
+		if err := transformState(c, sc.transforms, latestState, destWorkspaceId, destWorkSpaceName); err != nil {
+			sc.fail(srcworkspace.Name, "transform", err)
+		}
+	}
+
+	return nil
+}
+
+// Main function for `--state` flag
+func copyStates(c tfclient.ClientContexts, NumberOfStates int) error {
+
+	// Without a `workspaces` list or `workspaces-map` the copy of all source workspaces is confirmed
+	// when they are listed, which also confirms the warning below
+	allWorkspaces := len(viper.GetStringSlice("workspaces")) == 0 && len(viper.GetStringSlice("workspaces-map")) == 0
+
 	if NumberOfStates > 1 {
 		// fmt.Printf("\n\n**** Operation will migrate last %v states per workspace **** \n\n", NumberOfStates)
 		o.AddMessageUserProvided2("\n\n", fmt.Sprint(NumberOfStates), "states per workspace will be copied over.\n\nWarning:\n\n**** THIS OPERATION SHOULD NOT BE RAN MORE THAN ONCE ***")
 
-		if !confirm() {
+		if !allWorkspaces && !confirm() {
 			fmt.Println("\n\n**** Canceling tfm run **** ")
 			os.Exit(1)
 		}
 	}
 
+	// Get the source target workspaces
+	srcWorkspaces, err := getSrcWorkspacesCfg(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Workspaces from source")
+	}
+
+	sc, err := newStateCopy(NumberOfStates)
+	if err != nil {
+		return err
+	}
+
 	// Get/Check if Workspace map exists
 	wsMapCfg, err := helper.ViperStringSliceMap("workspaces-map")
 	if err != nil {
@@ -250,6 +672,8 @@ func copyStates(c tfclient.ClientContexts, NumberOfStates int) error {
 		return errors.Wrap(err, "failed to list Workspaces from source")
 	}
 
+	var skipped []interface{}
+
 	for _, srcworkspace := range srcWorkspaces {
 		destWorkSpaceName := srcworkspace.Name
 
@@ -262,174 +686,36 @@ func copyStates(c tfclient.ClientContexts, NumberOfStates int) error {
 		exists := doesWorkspaceExist(destWorkSpaceName, destWorkspaces)
 
 		if exists {
-			destWorkspaceId, err := getWorkspaceId(tfclient.GetClientContexts(), destWorkSpaceName)
-			if err != nil {
-				return errors.Wrap(err, "Failed to get the ID of the destination Workspace that matches the Name of the Source Workspace")
+			// Lock the source so no run starts between the active run check and the copy. Pending
+			// runs do not start while the source is locked, so they are only waited for when the
+			// source could not be locked.
+			srcLocked := lockSrcWorkspace(c, srcworkspace)
+
+			// A state copied while a run is planning or applying is stale once the run finishes
+			ready, err := helper.CheckActiveRuns(c.SourceContext, c.SourceClient, srcworkspace, onActiveRun, runTimeout, !srcLocked)
+			if err != nil || !ready {
+				unlockSrcWorkspace(c, srcworkspace, srcLocked)
 			}
-
-			fmt.Printf("Source ws %v has a matching ws %v in destination with ID %v. Comparing existing States...\n", srcworkspace.Name, destWorkSpaceName, destWorkspaceId)
-
-			// Get the source workspace states
-			srcStates, err := discoverSrcStates(tfclient.GetClientContexts(), srcworkspace.Name, NumberOfStates)
 			if err != nil {
-				return errors.Wrap(err, "failed to list state files for workspace from source")
+				return err
+			}
+			if !ready {
+				skipped = append(skipped, srcworkspace.Name)
+				continue
 			}
 
-			// Get the destination workspace states
-			destStates, err := discoverDestStates(tfclient.GetClientContexts(), destWorkSpaceName)
+			destWorkspaceId, err := getWorkspaceId(tfclient.GetClientContexts(), destWorkSpaceName)
 			if err != nil {
-				return errors.Wrap(err, "failed to list state files for workspace from destination")
+				unlockSrcWorkspace(c, srcworkspace, srcLocked)
+				return errors.Wrap(err, "Failed to get the ID of the destination Workspace that matches the Name of the Source Workspace")
 			}
 
-			// Loop each state for each source workspace with a matching workspace name in the destination,
-			// check for the existence of that states serial in destination, upload state if serial doesnt exist
-
-			for _, srcstate := range reverseSlice(srcStates) {
-
-				exists := doesStateExist(srcstate.Serial, destStates)
-				if exists {
-					fmt.Printf("State Version %v with Serial %v exists in destination will not migrate\n", srcstate.StateVersion, srcstate.Serial)
-				} else {
+			fmt.Printf("Source ws %v has a matching ws %v in destination with ID %v. Comparing existing States...\n", srcworkspace.Name, destWorkSpaceName, destWorkspaceId)
 
-					// Download state from source
-					state, err := downloadSourceState(tfclient.GetClientContexts(), srcstate.DownloadURL)
-
-					// Create an empty int
-					//newSerial := int64(1)
-
-					// Get properties of the state
-					// currentState, _ := c.DestinationClient.StateVersions.ReadCurrent(c.DestinationContext, destWorkspaceId)
-
-					// Of if there is a state file, set the newSerial variable to the current state serial + 1
-					// if currentState != nil {
-					// 	newSerial = currentState.Serial + 1
-					// }
-
-					// Get state file as string
-					plainTextState := string(state)
-
-					// Define a regular expression pattern to match the "serial" value
-					serialPattern := `"serial":\s*(\d+)`
-
-					// Compile the regular expression
-					serialRe := regexp.MustCompile(serialPattern)
-
-					// Find the match
-					serialMatch := serialRe.FindStringSubmatch(plainTextState)
-
-					if len(serialMatch) != 2 {
-						fmt.Println("Serial not found in JSON")
-						return err
-					}
-
-					newSerialConversion, err := strconv.ParseInt(serialMatch[1], 10, 64)
-					if err != nil {
-						fmt.Printf("The source state wasn't a int64")
-					}
-
-					// Define a regular expression pattern to match the "lineage" value
-					lineagePattern := `"lineage":\s*"([^"]+)"`
-
-					// Compile the regular expression
-					lineageRe := regexp.MustCompile(lineagePattern)
-
-					// Find the match
-					lineageMatch := lineageRe.FindStringSubmatch(plainTextState)
-
-					if len(lineageMatch) != 2 {
-						fmt.Println("Lineage not found in JSON")
-						return err
-					}
-
-					lineage := lineageMatch[1]
-					//fmt.Println("State JSON Lineage:", lineage)
-
-					// Base64 encode the state as a string
-					stringState := b64.StdEncoding.EncodeToString(state)
-
-					// Get the MD5 hash of the state
-					md5String := fmt.Sprintf("%x", md5.Sum([]byte(state)))
-
-					// Lock the destination workspace
-					lockWorkspace(tfclient.GetClientContexts(), destWorkspaceId)
-					fmt.Printf("Migrating state version %v serial %v for workspace Src: %v Dst: %v\n", srcstate.StateVersion, newSerialConversion, srcworkspace.Name, destWorkSpaceName)
-					// // ------------------------------------------------------------------------------
-					// // --- START rate limiting testing code ------------------------------------------
-					// // --- Comment out when not testing ------------------------------------------
-					// // ------------------------------------------------------------------------------
-					// Configure the rate limit to exceed 30 requests per second, set it to a higher value.
-					// requestsPerSecond := 1000
-					// requestInterval := time.Second / time.Duration(requestsPerSecond)
-
-					// // Create a wait group to wait for all goroutines to finish.
-					// var wg sync.WaitGroup
-
-					// // Launch multiple goroutines to make API requests.
-					// for i := 0; i < 1000; i++ { // Launch 100 goroutines
-					// 	wg.Add(1)
-					// 	go func() {
-					// 		defer wg.Done()
-
-					// 		// Simulate making an API request.
-					// 		resp, err := discoverDestTeams(tfclient.GetClientContexts())
-					// 		if err != nil {
-					// 			// Handle other errors here.
-					// 			fmt.Println("Error:", err)
-					// 			return
-					// 		}
-					// 		_ = resp
-					// 		// Sleep for the specified interval before making the next request.
-					// 		time.Sleep(requestInterval)
-
-					// 	}()
-					// }
-
-					// // Wait for all goroutines to finish.
-					// wg.Wait()
-
-					// fmt.Println("All API requests completed.")
-					// // ------------------------------------------------------------------------------
-					// // --- end rate limiting testing code ------------------------------------------
-					// // ------------------------------------------------------------------------------
-					srcstate, err := c.DestinationClient.StateVersions.Create(c.DestinationContext, destWorkspaceId, tfe.StateVersionCreateOptions{
-						Type:    "",
-						Lineage: &lineage,
-						MD5:     tfe.String(md5String),
-						Serial:  &newSerialConversion,
-						//Serial:           &newSerial,
-						State:            tfe.String(stringState),
-						Force:            new(bool),
-						Run:              &tfe.Run{},
-						JSONState:        new(string),
-						JSONStateOutputs: new(string),
-					})
-
-					if err != nil {
-						// Get the current timestamp and format it as a string
-						timestamp := time.Now().Format(time.RFC850)
-
-						// Replace colons with a different character to make the filename Windows-compatible
-						safeTimestamp := strings.ReplaceAll(timestamp, ":", "-")
-
-						// Create a file to store workspace names with errors
-						errorLogFileName := fmt.Sprintf("workspace_error_log_%s.txt", safeTimestamp)
-						errorLogFile, err := os.Create(errorLogFileName)
-						if err != nil {
-							fmt.Printf("Failed to create error log file: %v\n", err)
-							return err
-						}
-						defer errorLogFile.Close()
-
-						// If there is an error output the error, log it, and move onto the next workspace.
-						fmt.Println("failed to migrate state file. Moving onto next workspace.", err)
-						errorLogFile.WriteString(fmt.Sprintf("Failed to migrate state file for source workspace: %v\n", srcworkspace.Name))
-						break
-
-					}
-
-					_ = srcstate
-
-				}
+			err = sc.copyWorkspace(tfclient.GetClientContexts(), srcworkspace, destWorkspaceId, destWorkSpaceName)
+			unlockSrcWorkspace(c, srcworkspace, srcLocked)
+			if err != nil {
+				return err
 			}
 
 			unlockWorkspace(tfclient.GetClientContexts(), destWorkspaceId)
@@ -437,5 +723,22 @@ func copyStates(c tfclient.ClientContexts, NumberOfStates int) error {
 			fmt.Printf("Source workspace (%v) does not exist in destination (%v). No states to migrate\n", srcworkspace.Name, destWorkSpaceName)
 		}
 	}
+
+	if sc.backup != nil {
+		o.AddDeferredMessageRead("Destination states were backed up before the migration to", sc.backup.Dir)
+	}
+
+	if len(skipped) > 0 {
+		o.AddDeferredListMessageRead("Workspaces skipped because of active runs, copy their states again once the runs have finished", skipped)
+	}
+
+	if len(sc.older) > 0 {
+		o.AddDeferredListMessageRead("States skipped because they are older than the current destination state", sc.older)
+	}
+
+	if len(sc.failed) > 0 {
+		o.AddDeferredListMessageRead("Failed state migrations", sc.failed)
+	}
+
 	return nil
 }
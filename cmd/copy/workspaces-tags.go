@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package copy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// All functions related to copying workspace tags and tag bindings
+
+// A rule from the `tag-rules` list that converts a legacy flat tag into a key/value tag binding.
+// The key may reference capture groups of the pattern, the value is the last capture group.
+type tagRule struct {
+	pattern *regexp.Regexp
+	key     string
+}
+
+// Gets the `tag-rules` list from the config file. Each rule is in the format `regex=key`.
+// Rules are applied in order and the first matching rule wins.
+func getTagRules() ([]*tagRule, error) {
+	rules := []*tagRule{}
+
+	for _, r := range helper.ViperStringSlice("tag-rules") {
+		i := strings.LastIndex(r, "=")
+		if i <= 0 || i == len(r)-1 {
+			return nil, fmt.Errorf("invalid tag rule %q, expected regex=key", r)
+		}
+
+		pattern, err := regexp.Compile(r[:i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag rule %q: %v", r, err)
+		}
+
+		if pattern.NumSubexp() == 0 {
+			return nil, fmt.Errorf("invalid tag rule %q, the regex needs a capture group for the value", r)
+		}
+
+		rules = append(rules, &tagRule{pattern: pattern, key: r[i+1:]})
+	}
+
+	return rules, nil
+}
+
+// Converts a legacy flat tag into a tag binding with the first matching rule.
+func convertLegacyTag(tag string, rules []*tagRule) (*tfe.TagBinding, bool) {
+	for _, r := range rules {
+		match := r.pattern.FindStringSubmatchIndex(tag)
+		if match == nil {
+			continue
+		}
+
+		key := string(r.pattern.ExpandString(nil, r.key, tag, match))
+		groups := r.pattern.FindStringSubmatch(tag)
+		value := groups[len(groups)-1]
+
+		if key == "" {
+			continue
+		}
+
+		return &tfe.TagBinding{Key: key, Value: value}, true
+	}
+	return nil, false
+}
+
+// Get the tag bindings of a destination project. Workspace bindings that duplicate
+// these are inherited from the project and are not created on the workspace.
+func discoverDestProjTagBindings(c tfclient.ClientContexts, destProjectID string) []*tfe.TagBinding {
+	bindings, err := c.DestinationClient.Projects.ListTagBindings(c.DestinationContext, destProjectID)
+	if err != nil {
+		o.AddMessageUserProvided("Unable to read tag bindings of destination Project, inherited tags will not be checked:", err.Error())
+		return nil
+	}
+	return bindings
+}
+
+// Check if a tag binding with the same key and value exists in the provided bindings
+func doesTagBindingExist(binding *tfe.TagBinding, bindings []*tfe.TagBinding) bool {
+	for _, b := range bindings {
+		if b.Key == binding.Key && b.Value == binding.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// Builds the flat tags and tag bindings for a destination workspace from the source workspace.
+// Existing source tag bindings are copied as they are, legacy flat tags are converted with the
+// `tag-rules` and kept as flat tags if no rule matches. Bindings inherited from the destination
+// project are skipped.
+func getDestWorkspaceTags(c tfclient.ClientContexts, srcworkspace *tfe.Workspace, rules []*tagRule, projBindings []*tfe.TagBinding) ([]*tfe.Tag, []*tfe.TagBinding) {
+	tags := []*tfe.Tag{}
+	bindings := []*tfe.TagBinding{}
+
+	addBinding := func(b *tfe.TagBinding) {
+		if doesTagBindingExist(b, projBindings) {
+			o.AddFormattedMessageUserProvided2("Tag %v is inherited from the destination project, skipping for workspace %v", b.Key+"="+b.Value, srcworkspace.Name)
+			return
+		}
+		if !doesTagBindingExist(b, bindings) {
+			bindings = append(bindings, b)
+		}
+	}
+
+	// Releases without tag bindings return an error, only flat tags are copied then
+	srcBindings, err := c.SourceClient.Workspaces.ListTagBindings(c.SourceContext, srcworkspace.ID)
+	if err != nil {
+		srcBindings = nil
+	}
+
+	for _, b := range srcBindings {
+		addBinding(&tfe.TagBinding{Key: b.Key, Value: b.Value})
+	}
+
+	for _, t := range srcworkspace.TagNames {
+		if b, ok := convertLegacyTag(t, rules); ok {
+			o.AddFormattedMessageUserProvided3("Converting tag %v of workspace %v to %v", t, srcworkspace.Name, b.Key+"="+b.Value)
+			addBinding(b)
+			continue
+		}
+		if doesTagBindingExist(&tfe.TagBinding{Key: t}, projBindings) {
+			o.AddFormattedMessageUserProvided2("Tag %v is inherited from the destination project, skipping for workspace %v", t, srcworkspace.Name)
+			continue
+		}
+		tags = append(tags, &tfe.Tag{Name: t})
+	}
+
+	return tags, bindings
+}
@@ -5,6 +5,7 @@ package copy
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp-services/tfm/cmd/helper"
 	"github.com/hashicorp-services/tfm/tfclient"
@@ -12,92 +13,269 @@ import (
 	"github.com/pkg/errors"
 )
 
-// Copys variables from a source workspace to a destination workspace
-func variableCopy(c tfclient.ClientContexts, sourceWorkspaceID string, destinationWorkspaceID string, skipSensitive bool) error {
+// Policies for variables that exist in the destination workspace with different attributes
+const (
+	varConflictSkip      = "skip"
+	varConflictOverwrite = "overwrite"
+	varConflictFail      = "fail"
+	varConflictReport    = "report"
+)
+
+var varConflict string
+
+// Counts of the variables copied to a destination workspace
+type varCopySummary struct {
+	created   int
+	updated   int
+	skipped   int
+	conflicts []interface{}
+}
+
+// Validates the `--var-conflict` policy
+func validateVarConflict(policy string) error {
+	switch policy {
+	case varConflictSkip, varConflictOverwrite, varConflictFail, varConflictReport:
+		return nil
+	}
+	return fmt.Errorf("invalid --var-conflict %q, expected skip, overwrite, fail or report", policy)
+}
+
+// Gets all variables of a source workspace
+func discoverSrcWorkspaceVars(c tfclient.ClientContexts, workspaceID string) ([]*tfe.Variable, error) {
+	variables := []*tfe.Variable{}
+
+	opts := tfe.VariableListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100,
+		},
+	}
+
+	for {
+		items, err := c.SourceClient.Variables.List(c.SourceContext, workspaceID, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		variables = append(variables, items.Items...)
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return variables, nil
+}
+
+// Gets all variables of a destination workspace
+func discoverDestWorkspaceVars(c tfclient.ClientContexts, workspaceID string) ([]*tfe.Variable, error) {
+	variables := []*tfe.Variable{}
 
-	variableListOpts := tfe.VariableListOptions{
+	opts := tfe.VariableListOptions{
 		ListOptions: tfe.ListOptions{
 			PageNumber: 1,
 			PageSize:   100,
 		},
 	}
 
+	for {
+		items, err := c.DestinationClient.Variables.List(c.DestinationContext, workspaceID, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		variables = append(variables, items.Items...)
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return variables, nil
+}
+
+// Returns the destination variable with the same key and category. Terraform and
+// environment variables can share a key, a variable with the same key but another
+// category is a different variable.
+func getDestVar(srcVar *tfe.Variable, destVars []*tfe.Variable) (*tfe.Variable, bool) {
+	for _, v := range destVars {
+		if v.Key == srcVar.Key && v.Category == srcVar.Category {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Compares a source and destination variable and returns the differing attributes.
+// Sensitive values cannot be read from the source, so they are only compared when the
+// value was filled in from a secrets provider. A sensitive destination value cannot be
+// read either, so a value from a secrets provider always differs from it.
+func diffVar(srcVar *tfe.Variable, srcValue string, destVar *tfe.Variable) []string {
+	diffs := []string{}
+
+	if !srcVar.Sensitive && !destVar.Sensitive && srcValue != destVar.Value {
+		diffs = append(diffs, "value")
+	}
+	if srcVar.Sensitive && srcValue != "" && (destVar.Sensitive || srcValue != destVar.Value) {
+		diffs = append(diffs, "value")
+	}
+	if srcVar.HCL != destVar.HCL {
+		diffs = append(diffs, "hcl")
+	}
+	if srcVar.Sensitive != destVar.Sensitive {
+		diffs = append(diffs, "sensitive")
+	}
+	if srcVar.Description != destVar.Description {
+		diffs = append(diffs, "description")
+	}
+
+	return diffs
+}
+
+// Copys variables from a source workspace to a destination workspace. Sensitive values
+// are looked up by the source workspace name and variable key. Variables that exist in the
+// destination with different attributes are handled with the `--var-conflict` policy.
+func variableCopy(c tfclient.ClientContexts, sourceWorkspaceID string, sourceWorkspaceName string, destinationWorkspaceID string, skipSensitive bool, secrets *secretLookup, policy string) (*varCopySummary, error) {
+	summary := &varCopySummary{}
+
 	//Get all variables in source the workspace
-	srcWsVars, err := c.SourceClient.Variables.List(c.SourceContext, sourceWorkspaceID, &variableListOpts)
+	srcWsVars, err := discoverSrcWorkspaceVars(c, sourceWorkspaceID)
 	if err != nil {
-		fmt.Println("Could not list soruce Workspace variables.\n\n Error:", err.Error())
-		return err
+		fmt.Println("Could not list source Workspace variables.\n\n Error:", err.Error())
+		return summary, err
 	}
 
 	//Get all variables in destination the workspace
-	destWsVars, err := c.DestinationClient.Variables.List(c.DestinationContext, destinationWorkspaceID, &variableListOpts)
+	destWsVars, err := discoverDestWorkspaceVars(c, destinationWorkspaceID)
 	if err != nil {
 		fmt.Println("Could not list destination Workspace variables.\n\n Error:", err.Error())
-		return err
+		return summary, err
+	}
+
+	o.AddFormattedMessageCalculated2("Found %d variables in source workspace %v", len(srcWsVars), sourceWorkspaceName)
+
+	// Check for conflicts before any variable is created so a failed workspace is left unchanged
+	if policy == varConflictFail {
+		for _, workspaceVar := range srcWsVars {
+			if workspaceVar.Sensitive && skipSensitive {
+				continue
+			}
+			if destVar, exists := getDestVar(workspaceVar, destWsVars); exists {
+				if diffs := diffVar(workspaceVar, workspaceVar.Value, destVar); len(diffs) > 0 {
+					conflict := fmt.Sprintf("%v: %v differs (%v)", sourceWorkspaceName, workspaceVar.Key, strings.Join(diffs, ", "))
+					summary.conflicts = append(summary.conflicts, conflict)
+					return summary, errors.New("variable conflict in destination workspace " + conflict)
+				}
+			}
+		}
 	}
 
 	// For each variable in the source worksapce
-	for _, workspaceVar := range srcWsVars.Items {
+	for _, workspaceVar := range srcWsVars {
 		destVarName := workspaceVar.Key
 
-		//gather variables properties from source workspace. Variables marked as sensitive will be set to "" in the destination unless skipped
-		variableOpts := tfe.VariableCreateOptions{
-			Type:        "",
-			Key:         &workspaceVar.Key,
-			Value:       &workspaceVar.Value,
-			Description: &workspaceVar.Description,
-			Category:    &workspaceVar.Category,
-			HCL:         &workspaceVar.HCL,
-			Sensitive:   &workspaceVar.Sensitive,
+		if workspaceVar.Sensitive && skipSensitive {
+			o.AddMessageUserProvided(destVarName, "is sensitive and will not be copied")
+			summary.skipped++
+			continue
 		}
 
-		// Check for the existence of the variable with the same Key name in the destination
-		exists := doesVarExist(destVarName, destWsVars)
+		//gather variables properties from source workspace. Variables marked as sensitive will be filled in by the
+		//secrets provider, or set to "" in the destination
+		value := workspaceVar.Value
 
-		// If the variable exists in the destination, do nothing and inform the user
-		if exists {
-			o.AddMessageUserProvided("Exists in destination will not migrate", destVarName)
+		destVar, exists := getDestVar(workspaceVar, destWsVars)
 
-		} else if skipSensitive {
-			//Create the variable in the destination workspace but skip any sensitive ones
+		// Create the variable in the destination workspace if it does not exist
+		if !exists {
 			if workspaceVar.Sensitive {
-				o.AddMessageUserProvided(destVarName, "is sensitive and will not be copied")
-		} else {
-			//Create the variable in the destination workspace
-			o.AddMessageUserProvided("Copying", destVarName)
-			_, err := c.DestinationClient.Variables.Create(c.DestinationContext, destinationWorkspaceID, variableOpts)
-			if err != nil {
-				fmt.Println("Could not create Workspace variable.\n\n Error:", err.Error())
-				return err
+				secrets.fill(sourceWorkspaceName, workspaceVar.Key, &value)
 			}
-		} 
-	} else {
-			//Create the variable in the destination workspace
+
 			o.AddMessageUserProvided("Copying", destVarName)
-			_, err := c.DestinationClient.Variables.Create(c.DestinationContext, destinationWorkspaceID, variableOpts)
+			_, err := c.DestinationClient.Variables.Create(c.DestinationContext, destinationWorkspaceID, tfe.VariableCreateOptions{
+				Type:        "",
+				Key:         &workspaceVar.Key,
+				Value:       &value,
+				Description: &workspaceVar.Description,
+				Category:    &workspaceVar.Category,
+				HCL:         &workspaceVar.HCL,
+				Sensitive:   &workspaceVar.Sensitive,
+			})
 			if err != nil {
 				fmt.Println("Could not create Workspace variable.\n\n Error:", err.Error())
-				return err
+				return summary, err
 			}
+			summary.created++
+			continue
 		}
-	}
 
-	return nil
-}
+		// Sensitive values are only looked up when they could be written
+		if workspaceVar.Sensitive && policy == varConflictOverwrite {
+			value, _ = secrets.get(sourceWorkspaceName, workspaceVar.Key)
+		}
 
-// Compares the source variable key to existing destination variable keys
-func doesVarExist(workspaceName string, v *tfe.VariableList) bool {
-	for _, w := range v.Items {
-		if workspaceName == w.Key {
-			return true
+		diffs := diffVar(workspaceVar, value, destVar)
+		if len(diffs) == 0 {
+			o.AddMessageUserProvided("Exists in destination with the same attributes", destVarName)
+			summary.skipped++
+			continue
+		}
+
+		conflict := fmt.Sprintf("%v: %v differs (%v)", sourceWorkspaceName, destVarName, strings.Join(diffs, ", "))
+
+		switch policy {
+		case varConflictReport:
+			summary.conflicts = append(summary.conflicts, conflict)
+			summary.skipped++
+
+		case varConflictOverwrite:
+			opts := tfe.VariableUpdateOptions{
+				Type:        "",
+				Description: &workspaceVar.Description,
+				Category:    &workspaceVar.Category,
+				HCL:         &workspaceVar.HCL,
+				Sensitive:   &workspaceVar.Sensitive,
+			}
+
+			// Keep the existing sensitive value when no replacement value is known
+			if !workspaceVar.Sensitive || value != "" {
+				opts.Value = &value
+			}
+
+			o.AddMessageUserProvided("Overwriting", destVarName)
+			_, err := c.DestinationClient.Variables.Update(c.DestinationContext, destinationWorkspaceID, destVar.ID, opts)
+			if err != nil {
+				summary.conflicts = append(summary.conflicts, conflict+": "+err.Error())
+				summary.skipped++
+				continue
+			}
+			summary.updated++
+
+		default:
+			o.AddMessageUserProvided("Exists in destination will not migrate", destVarName)
+			summary.skipped++
 		}
 	}
-	return false
+
+	return summary, nil
 }
 
 // Main function used for --vars flag
 func copyVariables(c tfclient.ClientContexts, skipSecure bool) error {
 
+	if err := validateVarConflict(varConflict); err != nil {
+		return err
+	}
+
+	// Get the secrets provider used to fill in sensitive values
+	secrets, err := newSecretLookup(secretsProvider)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create the secrets provider")
+	}
+
 	// Get the source workspaces from the config file or ALL workspaces if non provided in the config file
 	srcWorkspaces, err := getSrcWorkspacesCfg(c)
 	if err != nil {
@@ -116,6 +294,9 @@ func copyVariables(c tfclient.ClientContexts, skipSecure bool) error {
 		return errors.Wrap(err, "failed to list Workspaces from source")
 	}
 
+	var conflicts []interface{}
+	o.AddTableHeaders("Source Workspace", "Destination Workspace", "Created", "Updated", "Skipped", "Conflicts")
+
 	// For each workspace
 	for _, srcworkspace := range srcWorkspaces {
 		destWorkSpaceName := srcworkspace.Name
@@ -138,7 +319,13 @@ func copyVariables(c tfclient.ClientContexts, skipSecure bool) error {
 			fmt.Printf("Source ws %v has a matching ws %v in destination with ID %v. Comparing and copying existing variables...\n", srcworkspace.Name, destWorkSpaceName, destWorkspaceId)
 
 			// Copy Variables from Source to Destination Workspace
-			variableCopy(c, srcworkspace.ID, destWorkspaceId, skipSensitive)
+			summary, err := variableCopy(c, srcworkspace.ID, srcworkspace.Name, destWorkspaceId, skipSecure, secrets, varConflict)
+			o.AddTableRows(srcworkspace.Name, destWorkSpaceName, summary.created, summary.updated, summary.skipped, len(summary.conflicts))
+			conflicts = append(conflicts, summary.conflicts...)
+			if err != nil {
+				o.Close()
+				return errors.Wrap(err, "Failed to copy variables to destination Workspace "+destWorkSpaceName)
+			}
 
 			// Unlock the workspace
 			unlockWorkspace(tfclient.GetClientContexts(), destWorkspaceId)
@@ -146,5 +333,12 @@ func copyVariables(c tfclient.ClientContexts, skipSecure bool) error {
 			fmt.Printf("Source workspace named %v does not exist in destination. No variables to migrate\n", srcworkspace.Name)
 		}
 	}
+
+	secrets.report()
+
+	if len(conflicts) > 0 {
+		o.AddDeferredListMessageRead("Variables that differ in destination", conflicts)
+	}
+
 	return nil
 }
@@ -31,6 +31,27 @@ func configureVCSsettings(c tfclient.ClientContexts, org string, vcsOptions tfe.
 	return workspace, nil
 }
 
+// Returns the options to connect a workspace to the repository of a VCS repo with the VCS
+// OAuth token or GitHub App installation ID provided. Returns false if the ID is neither.
+func getVCSRepoOptions(repo *tfe.VCSRepo, vcsID string) (tfe.VCSRepoOptions, bool) {
+	vcsConfig := tfe.VCSRepoOptions{
+		Branch:            &repo.Branch,
+		Identifier:        &repo.Identifier,
+		IngressSubmodules: &repo.IngressSubmodules,
+		TagsRegex:         &repo.TagsRegex,
+	}
+
+	if strings.HasPrefix(vcsID, "ot-") {
+		vcsConfig.OAuthTokenID = &vcsID
+	} else if strings.HasPrefix(vcsID, "ghain-") {
+		vcsConfig.GHAInstallationID = &vcsID
+	} else {
+		return vcsConfig, false
+	}
+
+	return vcsConfig, true
+}
+
 // Main function for --vcs flag
 func createVCSConfiguration(c tfclient.ClientContexts, vcsConfig map[string]string) error {
 
@@ -70,22 +91,12 @@ func createVCSConfiguration(c tfclient.ClientContexts, vcsConfig map[string]stri
 				if ws.VCSRepo.OAuthTokenID == srcvcs || ws.VCSRepo.GHAInstallationID == srcvcs {
 					o.AddFormattedMessageUserProvided2("Updating destination Workspace %v VCS Settings %v", destWorkSpaceName, destvcs)
 
-					vcsConfig := tfe.VCSRepoOptions{
-						Branch:            &ws.VCSRepo.Branch,
-						Identifier:        &ws.VCSRepo.Identifier,
-						IngressSubmodules: &ws.VCSRepo.IngressSubmodules,
-						TagsRegex:         &ws.VCSRepo.TagsRegex,
-					}
-
-					if strings.HasPrefix(destvcs, "ot-") {
-						vcsConfig.OAuthTokenID = &destvcs
-					} else if strings.HasPrefix(destvcs, "ghain-") {
-						vcsConfig.GHAInstallationID = &destvcs
-					} else {
+					vcsConfig, ok := getVCSRepoOptions(ws.VCSRepo, destvcs)
+					if !ok {
 						o.AddFormattedMessageUserProvided2("Invalid destination VCS ID %v for Workspace %v. Skipping.", destvcs, destWorkSpaceName)
 						continue
 					}
-					
+
 					configureVCSsettings(c, c.DestinationOrganizationName, vcsConfig, destWorkSpaceName)
 				} else {
 
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/cmd/preflight"
 	"github.com/hashicorp-services/tfm/tfclient"
 	tfe "github.com/hashicorp/go-tfe"
 	"github.com/pkg/errors"
@@ -28,6 +30,8 @@ var (
 	consolidateGlobal  bool
 	last               int
 	runTriggers        bool
+	configVersions     bool
+	onActiveRun        string
 
 	// `tfemigrate copy workspaces` command
 	workspacesCopyCmd = &cobra.Command{
@@ -109,6 +113,9 @@ var (
 
 			case runTriggers:
 				return copyRunTriggers(tfclient.GetClientContexts())
+
+			case configVersions:
+				return copyConfigVersions(tfclient.GetClientContexts(), last)
 			}
 
 			return copyWorkspaces(
@@ -126,17 +133,31 @@ func init() {
 	workspacesCopyCmd.Flags().String("workspace-id", "", "Specify one single workspace ID to copy to destination")
 	workspacesCopyCmd.Flags().BoolVarP(&vars, "vars", "", false, "Copy workspace variables")
 	workspacesCopyCmd.Flags().BoolVarP(&skipSensitive, "skip-sensitive-vars", "", false, "Skip copying sensitive variables. Must be used with --vars flag")
+	workspacesCopyCmd.Flags().StringVarP(&varConflict, "var-conflict", "", "skip", "Policy for variables that exist in the destination with different attributes: skip, overwrite, fail or report. Must be used with --vars flag")
+	workspacesCopyCmd.Flags().StringVarP(&secretsProvider, "secrets-provider", "", "", "Fill in sensitive variable values from a secrets provider: file, env or command. Must be used with --vars flag")
 	workspacesCopyCmd.Flags().BoolVarP(&state, "state", "", false, "Copy workspace states")
-	workspacesCopyCmd.Flags().IntVarP(&last, "last", "l", last, "Copy the last X number of state files only.")
+	workspacesCopyCmd.Flags().IntVarP(&last, "last", "l", last, "Copy the last X number of state files or configuration versions only.")
+	workspacesCopyCmd.Flags().StringVarP(&stateSince, "since", "", "", "Copy state versions created on or after a date (YYYY-MM-DD or RFC3339). Must be used with --state flag")
+	workspacesCopyCmd.Flags().StringVarP(&stateUntil, "until", "", "", "Copy state versions created on or before a date (YYYY-MM-DD or RFC3339). Must be used with --state flag")
+	workspacesCopyCmd.Flags().StringVarP(&stateSerials, "serials", "", "", "Copy state versions with these serials, such as 10-25 or 3,7,10-25. Must be used with --state flag")
+	workspacesCopyCmd.Flags().BoolVarP(&stateOnlyCurrent, "only-current", "", false, "Copy the current state version only. Must be used with --state flag")
+	workspacesCopyCmd.Flags().StringVarP(&onActiveRun, "on-active-run", "", helper.OnActiveRunWait, "What to do with source workspaces with active or pending runs: wait, skip or fail. Must be used with --state flag")
+	workspacesCopyCmd.Flags().DurationVarP(&runTimeout, "run-timeout", "", 30*time.Minute, "How long to wait for the active runs of a source workspace with --on-active-run=wait before it is skipped")
 	// SetInterspersed prevents cobra from parsing arguments that appear after flags
 	workspacesCopyCmd.Flags().SetInterspersed(false)
 
 	// Prevents users from using the --last flag in an unwanted way
 	workspacesCopyCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
-		if last > 0 && !state {
-			return errors.New("--last flag is only valid after the --state flag is set")
+		if last > 0 && !state && !configVersions {
+			return errors.New("--last flag is only valid after the --state or --config-versions flag is set")
+		}
+		if (stateSince != "" || stateUntil != "" || stateSerials != "" || stateOnlyCurrent) && !state {
+			return errors.New("--since, --until, --serials and --only-current flags are only valid after the --state flag is set")
 		}
-		return nil
+		if (cmd.Flags().Lookup("on-active-run").Changed || cmd.Flags().Lookup("run-timeout").Changed) && !state {
+			return errors.New("--on-active-run and --run-timeout flags are only valid after the --state flag is set")
+		}
+		return helper.ValidateOnActiveRun(onActiveRun)
 	}
 	workspacesCopyCmd.Flags().BoolVarP(&teamaccess, "teamaccess", "", false, "Copy workspace Team Access")
 	workspacesCopyCmd.Flags().BoolVarP(&agents, "agents", "", false, "Mapping of source Agent Pool IDs to destination Agent Pool IDs in config file")
@@ -145,6 +166,7 @@ func init() {
 	workspacesCopyCmd.Flags().BoolVarP(&remoteStateSharing, "remote-state-sharing", "", false, "Copy remote state sharing settings")
 	workspacesCopyCmd.Flags().BoolVarP(&consolidateGlobal, "consolidate-global", "", false, "Consolidate global remote state sharing settings. Must be used with --remote-state-sharing flag")
 	workspacesCopyCmd.Flags().BoolVarP(&runTriggers, "run-triggers", "", false, "Copy workspace run triggers")
+	workspacesCopyCmd.Flags().BoolVarP(&configVersions, "config-versions", "", false, "Copy the latest configuration version of CLI/API driven workspaces. Use --last to copy more")
 
 	// Add commands
 	CopyCmd.AddCommand(workspacesCopyCmd)
@@ -421,30 +443,43 @@ func copyWorkspaces(c tfclient.ClientContexts, wsMapCfg map[string]string) error
 		project.ID = viper.GetString("dst_tfc_project_id")
 		o.AddMessageUserProvided("Destination Project ID is Set: ", project.ID)
 
+	} else if preflight.Destination != nil && !preflight.Destination.Projects {
+
+		// Releases without projects create the workspaces without a project
+		o.AddMessageUserProvided("Projects are disabled, they are not supported by the destination:", preflight.Destination.Release())
+
 	} else {
 
 		// get Default Project ID
 		project.ID, err = getDstDefaultProjectID(c)
 
 		if err != nil {
-			fmt.Println("Error Retrieving Destination Project ID. Destination TFE/TFC API may not be supported. Please check credentials")
-			os.Exit(0)
+			return errors.Wrap(err, "Failed to read the Default Project of the destination. Run `tfm preflight` to check the destination token and release")
 		}
 	}
 
+	var workspaceProject *tfe.Project
+	if project.ID != "" {
+		workspaceProject = &project
+	}
+
+	// Get the rules to convert legacy flat tags to tag bindings
+	tagRules, err := getTagRules()
+	if err != nil {
+		return errors.Wrap(err, "Invalid input for tag-rules")
+	}
+
+	// Tag bindings inherited from the destination project are not copied to the workspaces
+	var projBindings []*tfe.TagBinding
+	if project.ID != "" {
+		projBindings = discoverDestProjTagBindings(c, project.ID)
+	}
+
 	// Loop each workspace in the srcWorkspaces slice, check for the workspace existence in the destination,
 	// and if a workspace exists in the destination, then do nothing, else create workspace in destination.
 	for _, srcworkspace := range srcWorkspaces {
 		destWorkSpaceName := srcworkspace.Name
 
-		// Copy tags over
-		var tag []*tfe.Tag
-		// workspaceSource := "tfm"
-
-		for _, t := range srcworkspace.TagNames {
-			tag = append(tag, &tfe.Tag{Name: t})
-		}
-
 		// Check if the destination Workspace name differs from the source name
 		if len(wsMapCfg) > 0 {
 			o.AddMessageUserProvided3("Source Workspace:", srcworkspace.Name, "\nDestination Workspace:", wsMapCfg[srcworkspace.Name])
@@ -456,6 +491,9 @@ func copyWorkspaces(c tfclient.ClientContexts, wsMapCfg map[string]string) error
 		if exists {
 			o.AddMessageUserProvided2(destWorkSpaceName, "exists in destination will not migrate", srcworkspace.Name)
 		} else {
+			// Copy tags and tag bindings over
+			tag, tagBindings := getDestWorkspaceTags(c, srcworkspace, tagRules, projBindings)
+
 			srcworkspace, err := c.DestinationClient.Workspaces.Create(c.DestinationContext, c.DestinationOrganizationName, tfe.WorkspaceCreateOptions{
 				Type: "",
 				// AgentPoolID:        new(string), covered with `assignAgentPool` function
@@ -474,7 +512,8 @@ func copyWorkspaces(c tfclient.ClientContexts, wsMapCfg map[string]string) error
 				TriggerPatterns:            srcworkspace.TriggerPatterns,
 				WorkingDirectory:           &srcworkspace.WorkingDirectory,
 				Tags:                       tag,
-				Project:                    &project,
+				TagBindings:                tagBindings,
+				Project:                    workspaceProject,
 			})
 			if err != nil {
 				fmt.Println("Could not create Workspace.\n\n Error:", err.Error())
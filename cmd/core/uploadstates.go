@@ -4,7 +4,6 @@
 package core
 
 import (
-	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -14,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
 	"github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -36,10 +36,6 @@ func init() {
 	CoreCmd.AddCommand(UploadStateCmd)
 }
 
-type TerraformState struct {
-	Lineage string `json:"lineage"`
-}
-
 // Loads the metadata file information for use
 func loadMetadataUploadState(metadataFile string) ([]RepoConfig, error) {
 	var metadata []RepoConfig
@@ -235,6 +231,11 @@ func uploadStateFiles(c tfclient.DestinationContexts, clonePath string) error {
 		return fmt.Errorf("error loading metadata: %v", err)
 	}
 
+	transforms, err := tfstate.TransformsFromConfig()
+	if err != nil {
+		return fmt.Errorf("invalid state transforms: %v", err)
+	}
+
 	for _, repoConfig := range metadata {
 		for _, configPath := range repoConfig.ConfigPaths {
 			for _, wsName := range configPath.WorkspaceInfo.WorkspaceNames {
@@ -273,21 +274,49 @@ func uploadStateFiles(c tfclient.DestinationContexts, clonePath string) error {
 					continue
 				}
 
-				var tfState TerraformState
-				if err = json.Unmarshal(stateFileContent, &tfState); err != nil {
-					fmt.Printf("Failed to unmarshal state file: %v\n", err)
+				tfState, err := tfstate.Parse(stateFileContent)
+				if err != nil {
+					fmt.Printf("Failed to parse state file %s: %v\n", tfstatePath, err)
 					continue
 				}
 
+				// Apply the state transforms from the config file
+				if !transforms.Empty() {
+					result, err := tfstate.Transform(stateFileContent, transforms)
+					if err != nil {
+						fmt.Printf("Failed to transform state file %s: %v\n", tfstatePath, err)
+						continue
+					}
+					if len(result.Changes) > 0 {
+						fmt.Printf("Transforming state file %s:\n%v", tfstatePath, result.Diff())
+						stateFileContent, tfState = result.State, result.Parsed
+					}
+				}
+
 				stringState := base64.StdEncoding.EncodeToString(stateFileContent)
-				md5String := fmt.Sprintf("%x", md5.Sum(stateFileContent))
+				md5String := tfstate.MD5(stateFileContent)
 
-				_, err = c.DestinationClient.StateVersions.Create(c.DestinationContext, workspace.ID, tfe.StateVersionCreateOptions{
-					Serial:  tfe.Int64(1),
+				createOptions := tfe.StateVersionCreateOptions{
+					Serial:  tfe.Int64(tfState.Serial),
 					Lineage: tfe.String(tfState.Lineage),
 					MD5:     tfe.String(md5String),
 					State:   tfe.String(stringState),
-				})
+				}
+
+				// Generate the JSON state and outputs so the workspace shows outputs before the first run.
+				// Without them, for example for a version 3 state, the destination populates them on the next run.
+				if data, err := tfstate.JSONState(stateFileContent); err != nil {
+					fmt.Printf("Unable to generate the JSON state of %s: %v\n", tfstatePath, err)
+				} else {
+					createOptions.JSONState = tfe.String(base64.StdEncoding.EncodeToString(data))
+				}
+				if data, err := tfstate.JSONOutputs(tfState); err != nil {
+					fmt.Printf("Unable to generate the JSON outputs of %s: %v\n", tfstatePath, err)
+				} else {
+					createOptions.JSONStateOutputs = tfe.String(base64.StdEncoding.EncodeToString(data))
+				}
+
+				_, err = c.DestinationClient.StateVersions.Create(c.DestinationContext, workspace.ID, createOptions)
 				if err != nil {
 					fmt.Printf("Failed to upload state file to workspace %s: %v\n", workspaceName, err)
 					continue
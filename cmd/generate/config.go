@@ -99,6 +99,59 @@ dst_tfc_token="Must have owner permissions"
 #  "sshkey-sPLAKMcqnWtHPSgx=sshkey-CRLmPJpoHwsNFAoN"
 #]
 
+# A list of source=destination team names. Used by tfm copy projects --teamaccess to match teams whose name differs in the destination.
+#teams-map = [
+#  "platform-admins=cloud-platform-admins"
+#]
+
+# A list of source=destination user emails. Used by tfm copy teams --members to resolve team members whose email address differs in the destination.
+#users-map = [
+#  "jane.doe@old-company.com=jane.doe@new-company.com"
+#]
+
+# A list of regex=key rules. Used by tfm copy workspaces to convert flat tags into key/value tag bindings. The value is the last capture group of the regex.
+#tag-rules = [
+#  "^env:(.+)$=env",
+#  "^team-(.+)$=team"
+#]
+
+# The secrets provider used to fill in sensitive variable values: file, env or command.
+#secrets-provider = "file"
+#secrets-file = "secrets.enc.json"
+#secrets-command = "./get-secret.sh"
+
+# State versions copied by tfm copy workspaces --state for a source workspace, in place of the --since, --until, --serials and --only-current flags.
+#state-since = [
+#  "src-workspace=2024-01-01"
+#]
+#state-until = [
+#  "src-workspace=2024-06-30"
+#]
+#state-serials = [
+#  "src-workspace=10-25"
+#]
+#state-only-current = [
+#  "src-workspace"
+#]
+
+# Transforms applied to the latest state of each workspace by tfm copy workspaces --state and tfm core upload-state.
+#state-replace-providers = [
+#  "registry.example.com/acme/aws=registry.terraform.io/hashicorp/aws"
+#]
+#state-moves = [
+#  "module.old_name=module.new_name",
+#  "aws_instance.old=aws_instance.new"
+#]
+#state-removals = [
+#  "data.aws_ami.ubuntu",
+#  "module.workspace_specific"
+#]
+#state-regenerate-lineage = false
+#state-backup-dir = "state-backups"
+
+# Skip the preflight checks of token permissions, entitlements and limits that run before each command
+#skip-preflight = false
+
 # THE FOLLOWING ARE ONLY USED FOR MIGRATING FROM TERRAFORM OPEN SOURCE / COMMUNITY EDITION TO TFE/TFC
 
 #commit_message = "A commit message the tfm core remove-backend command uses when removing backend blocks from .tf files and commiting the changes back"
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/hashicorp-services/tfm/secrets"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsIn  string
+	secretsOut string
+
+	// `tfm generate secrets-file` command
+	generateSecretsFileCmd = &cobra.Command{
+		Use:   "secrets-file",
+		Short: "Encrypt a secrets file",
+		Long:  "Encrypt a plain JSON secrets file used by the file secrets provider. The passphrase is read from TFM_SECRETS_PASSPHRASE.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return generateSecretsFile(secretsIn, secretsOut)
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	generateSecretsFileCmd.Flags().StringVarP(&secretsIn, "in", "", "secrets.json", "Plain JSON secrets file to encrypt")
+	generateSecretsFileCmd.Flags().StringVarP(&secretsOut, "out", "", "secrets.enc.json", "Encrypted secrets file to write")
+
+	// Add commands
+	GenerateCmd.AddCommand(generateSecretsFileCmd)
+}
+
+func generateSecretsFile(in string, out string) error {
+	plaintext, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the file is in the expected format before encrypting it
+	values := map[string]map[string]string{}
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return errors.Wrap(err, "secrets file must be in the format {\"<owner>\": {\"<key>\": \"<value>\"}}")
+	}
+
+	encrypted, err := secrets.EncryptFile(plaintext, os.Getenv(secrets.PassphraseEnv))
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(out, encrypted, 0600); err != nil {
+		return err
+	}
+
+	o.AddMessageUserProvided("Encrypted secrets file written to", out)
+
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// The statuses of runs that have started and not yet finished. Pending runs are not included,
+// they do not start while a workspace is locked.
+var ActiveRunStatuses = []tfe.RunStatus{
+	tfe.RunFetching,
+	tfe.RunFetchingCompleted,
+	tfe.RunPrePlanRunning,
+	tfe.RunPrePlanCompleted,
+	tfe.RunQueuing,
+	tfe.RunPlanQueued,
+	tfe.RunPlanning,
+	tfe.RunPlanned,
+	tfe.RunCostEstimating,
+	tfe.RunCostEstimated,
+	tfe.RunPolicyChecking,
+	tfe.RunPolicyOverride,
+	tfe.RunPolicySoftFailed,
+	tfe.RunPolicyChecked,
+	tfe.RunPostPlanRunning,
+	tfe.RunPostPlanCompleted,
+	tfe.RunPostPlanAwaitingDecision,
+	tfe.RunConfirmed,
+	tfe.RunPreApplyRunning,
+	tfe.RunPreApplyCompleted,
+	tfe.RunQueuingApply,
+	tfe.RunApplyQueued,
+	tfe.RunApplying,
+}
+
+// How often WaitForRuns checks the runs of a workspace
+const runPollInterval = 10 * time.Second
+
+// The `--on-active-run` policies for workspaces with active runs
+const (
+	OnActiveRunWait = "wait"
+	OnActiveRunSkip = "skip"
+	OnActiveRunFail = "fail"
+)
+
+// Returned by WaitForRuns when the runs of a workspace did not finish within the timeout
+var ErrRunsNotFinished = errors.New("runs did not finish")
+
+// Validates an `--on-active-run` policy
+func ValidateOnActiveRun(policy string) error {
+	switch policy {
+	case OnActiveRunWait, OnActiveRunSkip, OnActiveRunFail:
+		return nil
+	}
+	return fmt.Errorf("invalid --on-active-run %q, expected wait, skip or fail", policy)
+}
+
+// Lists the runs of a workspace that have started and not yet finished, and the pending runs
+// in the queue of the workspace if pending is true
+func ActiveRuns(ctx context.Context, client *tfe.Client, workspaceID string, pending bool) ([]*tfe.Run, error) {
+	active := map[tfe.RunStatus]bool{}
+	statuses := []string{}
+	for _, s := range ActiveRunStatuses {
+		active[s] = true
+		statuses = append(statuses, string(s))
+	}
+	if pending {
+		active[tfe.RunPending] = true
+		statuses = append(statuses, string(tfe.RunPending))
+	}
+
+	runs := []*tfe.Run{}
+
+	opts := tfe.RunListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+		Status:      strings.Join(statuses, ","),
+	}
+	for {
+		items, err := client.Runs.List(ctx, workspaceID, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Older TFE releases ignore the status filter
+		for _, r := range items.Items {
+			if active[r.Status] {
+				runs = append(runs, r)
+			}
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return runs, nil
+}
+
+// Waits until a workspace has no active runs. Returns ErrRunsNotFinished with the active runs
+// if they have not finished within the timeout.
+func WaitForRuns(ctx context.Context, client *tfe.Client, workspaceID string, timeout time.Duration, pending bool) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		runs, err := ActiveRuns(ctx, client, workspaceID, pending)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w within %v: %v", ErrRunsNotFinished, timeout, FormatRuns(runs))
+		}
+
+		fmt.Printf("Waiting for %d active runs to finish: %v\n", len(runs), FormatRuns(runs))
+		time.Sleep(runPollInterval)
+	}
+}
+
+// Applies an `--on-active-run` policy to a workspace. With `wait` it waits for the active runs
+// up to the timeout, with `skip` it does not wait and with `fail` it returns an error if the
+// workspace has active runs. Returns false if the workspace should be skipped.
+func CheckActiveRuns(ctx context.Context, client *tfe.Client, workspace *tfe.Workspace, policy string, timeout time.Duration, pending bool) (bool, error) {
+	runs, err := ActiveRuns(ctx, client, workspace.ID, pending)
+	if err != nil {
+		return false, fmt.Errorf("failed to list the runs of workspace %v: %v", workspace.Name, err)
+	}
+	if len(runs) == 0 {
+		return true, nil
+	}
+
+	switch policy {
+	case OnActiveRunFail:
+		return false, fmt.Errorf("workspace %v has active runs: %v", workspace.Name, FormatRuns(runs))
+	case OnActiveRunSkip:
+		fmt.Printf("Skipping workspace %v with active runs: %v\n", workspace.Name, FormatRuns(runs))
+		return false, nil
+	}
+
+	err = WaitForRuns(ctx, client, workspace.ID, timeout, pending)
+	if errors.Is(err, ErrRunsNotFinished) {
+		fmt.Printf("Skipping workspace %v: %v\n", workspace.Name, err)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to list the runs of workspace %v: %v", workspace.Name, err)
+	}
+
+	return true, nil
+}
+
+// Returns true if a run has finished, pending runs have not started
+func RunFinished(r *tfe.Run) bool {
+	if r.Status == tfe.RunPending {
+		return false
+	}
+	for _, s := range ActiveRunStatuses {
+		if r.Status == s {
+			return false
+		}
+	}
+	return true
+}
+
+// Formats runs as a list of run IDs and statuses
+func FormatRuns(runs []*tfe.Run) string {
+	list := []string{}
+	for _, r := range runs {
+		list = append(list, fmt.Sprintf("%v (%v)", r.ID, r.Status))
+	}
+	return strings.Join(list, ", ")
+}
@@ -5,6 +5,10 @@ package helper
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -94,3 +98,48 @@ func ViperMapKeyValuePair(flag string) (string, string, error) {
 	}
 	return s1, s2, nil
 }
+
+// Writes a map back to the configuration file in use as a list of "a=1" strings,
+// replacing any existing definition of the same key. The map is also set in viper
+// so the rest of the tfm run can use it.
+func ViperWriteStringSliceMap(flag string, m map[string]string) error {
+	var values []string
+	for k, v := range m {
+		values = append(values, k+"="+v)
+	}
+	sort.Strings(values)
+
+	viper.Set(flag, values)
+
+	cfgFile := viper.ConfigFileUsed()
+	if cfgFile == "" {
+		return errors.New("no configuration file in use, unable to write " + flag)
+	}
+
+	info, err := os.Stat(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	// Remove any existing uncommented definition of the list
+	re := regexp.MustCompile(`(?ms)^[ \t]*"?` + regexp.QuoteMeta(flag) + `"?[ \t]*=[ \t]*\[.*?\][ \t]*\n?`)
+	content = re.ReplaceAll(content, nil)
+
+	var b strings.Builder
+	b.Write(content)
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("\n%s = [\n", flag))
+	for _, v := range values {
+		b.WriteString(fmt.Sprintf("  \"%s\",\n", v))
+	}
+	b.WriteString("]\n")
+
+	return os.WriteFile(cfgFile, []byte(b.String()), info.Mode().Perm())
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/spf13/viper"
+)
+
+// Gets the workspace names of a side from the `workspaces` list or `workspaces-map` in the config
+// file. Source workspaces are the keys of `workspaces-map` and destination workspaces the values.
+// No names means all workspaces of the side.
+func GetWorkspaceNamesCfg(side string) ([]string, error) {
+	wsList := viper.GetStringSlice("workspaces")
+
+	wsMapCfg, err := ViperStringSliceMap("workspaces-map")
+	if err != nil {
+		return nil, errors.New("Invalid input for workspaces-map")
+	}
+
+	if len(wsList) > 0 && len(wsMapCfg) > 0 {
+		return nil, errors.New("'workspaces' list and 'workspaces-map' cannot be defined at the same time.")
+	}
+
+	for src, dest := range wsMapCfg {
+		if side == "destination" {
+			wsList = append(wsList, dest)
+		} else {
+			wsList = append(wsList, src)
+		}
+	}
+
+	return wsList, nil
+}
+
+// Gets the workspaces of a side from the `workspaces` list or `workspaces-map` in the config file,
+// see GetWorkspaceNamesCfg. If neither is configured all workspaces of the organization are returned.
+func GetWorkspacesCfg(client *tfe.Client, ctx context.Context, org string, side string) ([]*tfe.Workspace, error) {
+	wsList, err := GetWorkspaceNamesCfg(side)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := []*tfe.Workspace{}
+
+	if len(wsList) > 0 {
+		for _, name := range wsList {
+			ws, err := client.Workspaces.Read(ctx, org, name)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read workspace %v from %v organization %v: %w", name, side, org, err)
+			}
+			workspaces = append(workspaces, ws)
+		}
+		return workspaces, nil
+	}
+
+	opts := tfe.WorkspaceListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100},
+	}
+	for {
+		items, err := client.Workspaces.List(ctx, org, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, items.Items...)
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return workspaces, nil
+}
@@ -0,0 +1,257 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package list
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/pkg/errors"
+)
+
+// All functions related to comparing the objects of the source and destination sides
+
+// The statuses of a compared object
+const (
+	diffOnlyInSource      = "only-in-source"
+	diffOnlyInDestination = "only-in-destination"
+	diffDifferent         = "different"
+	diffSame              = "same"
+)
+
+// An object listed on one side. Objects are matched by name, or by ID with an ID map.
+// IDs always differ between sides, so they are not compared.
+type diffItem struct {
+	Name       string
+	ID         string
+	Attributes map[string]string
+}
+
+// Lists the objects of one side to compare them
+type diffLister func(c tfclient.ClientContexts, side string) ([]diffItem, error)
+
+// The config file maps used to match source objects with destination objects
+type diffMaps struct {
+	// source=destination names
+	names map[string]string
+	// source=destination IDs
+	ids map[string]string
+}
+
+// The source and destination values of an attribute
+type diffValues struct {
+	Source      string `json:"source" yaml:"source"`
+	Destination string `json:"destination" yaml:"destination"`
+}
+
+// The differing attributes of an object
+type diffDifferences map[string]diffValues
+
+// A source object and its matching destination object
+type diffEntry struct {
+	Status      string
+	Source      string
+	Destination string
+	Differences diffDifferences
+}
+
+// The organization of a side
+type diffSide struct {
+	Hostname     string `json:"hostname" yaml:"hostname"`
+	Organization string `json:"organization" yaml:"organization"`
+}
+
+// The comparison of the objects of the source and destination sides. Entries lists the objects
+// that are only on one side or that differ, Counts has the number of objects for each status.
+type diffReport struct {
+	Resource    string         `json:"resource" yaml:"resource"`
+	Source      diffSide       `json:"source" yaml:"source"`
+	Destination diffSide       `json:"destination" yaml:"destination"`
+	Counts      map[string]int `json:"counts" yaml:"counts"`
+	Entries     []record       `json:"entries" yaml:"entries"`
+}
+
+// The schema of the entries of `--diff`
+var diffColumns = []column{
+	{"status", "Status"},
+	{"source", "Source"},
+	{"destination", "Destination"},
+	{"differences", "Differences"},
+}
+
+// Checks the --side and --diff flags. Returns true if the sides should be compared.
+func isDiff() (bool, error) {
+	if side == "both" && !diff {
+		return false, errors.New("--side both is only valid with the --diff flag")
+	}
+	if diff && side != "both" {
+		return false, errors.New("--diff flag is only valid with --side both")
+	}
+	return diff, nil
+}
+
+// Reads the source=destination names and IDs maps from the config file
+func getDiffMaps(namesMap string, idsMap string) (diffMaps, error) {
+	maps := diffMaps{names: map[string]string{}, ids: map[string]string{}}
+
+	if namesMap != "" {
+		names, err := helper.ViperStringSliceMap(namesMap)
+		if err != nil {
+			return maps, fmt.Errorf("invalid input for %v", namesMap)
+		}
+		maps.names = names
+	}
+
+	if idsMap != "" {
+		ids, err := helper.ViperStringSliceMap(idsMap)
+		if err != nil {
+			return maps, fmt.Errorf("invalid input for %v", idsMap)
+		}
+		maps.ids = ids
+	}
+
+	return maps, nil
+}
+
+// Returns the destination name of a source name
+func (m diffMaps) name(name string) string {
+	if mapped, ok := m.names[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+// Matches the source objects with the destination objects and compares their attributes
+func compareItems(src []diffItem, dest []diffItem, maps diffMaps) []diffEntry {
+	destByName := map[string]diffItem{}
+	destByID := map[string]diffItem{}
+	for _, d := range dest {
+		destByName[d.Name] = d
+		if d.ID != "" {
+			destByID[d.ID] = d
+		}
+	}
+
+	entries := []diffEntry{}
+	matched := map[string]bool{}
+
+	for _, s := range src {
+		d, ok := destByID[maps.ids[s.ID]]
+		if !ok {
+			d, ok = destByName[maps.name(s.Name)]
+		}
+		if !ok {
+			entries = append(entries, diffEntry{Status: diffOnlyInSource, Source: s.Name})
+			continue
+		}
+		matched[d.Name] = true
+
+		entry := diffEntry{Status: diffSame, Source: s.Name, Destination: d.Name}
+		for key, value := range s.Attributes {
+			if d.Attributes[key] != value {
+				if entry.Differences == nil {
+					entry.Differences = diffDifferences{}
+				}
+				entry.Differences[key] = diffValues{Source: value, Destination: d.Attributes[key]}
+			}
+		}
+		for key, value := range d.Attributes {
+			if _, ok := s.Attributes[key]; !ok && value != "" {
+				if entry.Differences == nil {
+					entry.Differences = diffDifferences{}
+				}
+				entry.Differences[key] = diffValues{Destination: value}
+			}
+		}
+		if len(entry.Differences) > 0 {
+			entry.Status = diffDifferent
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, d := range dest {
+		if !matched[d.Name] {
+			entries = append(entries, diffEntry{Status: diffOnlyInDestination, Destination: d.Name})
+		}
+	}
+
+	return entries
+}
+
+// Formats the differences of an entry as `attribute: source -> destination`
+func formatDifferences(differences map[string]diffValues) string {
+	keys := []string{}
+	for key := range differences {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := []string{}
+	for _, key := range keys {
+		list = append(list, fmt.Sprintf("%v: %q -> %q", key, differences[key].Source, differences[key].Destination))
+	}
+	return strings.Join(list, ", ")
+}
+
+// Formats the differences as `attribute: source -> destination`
+func (d diffDifferences) String() string {
+	return formatDifferences(d)
+}
+
+// Lists the objects of both sides, compares them and outputs the objects that are only on one
+// side or differ in the `--format` format. The json and yaml formats include the counts.
+func listDiff(c tfclient.ClientContexts, resource string, lister diffLister, maps diffMaps) error {
+	l, err := newListing(resource, diffColumns)
+	if err != nil {
+		return err
+	}
+
+	o.AddMessageUserProvided3("Comparing "+resource+" of", c.SourceHostname+"/"+c.SourceOrganizationName, "with", c.DestinationHostname+"/"+c.DestinationOrganizationName)
+
+	src, err := lister(c, "source")
+	if err != nil {
+		return errors.Wrapf(err, "failed to list %v from %v", resource, c.SourceHostname)
+	}
+
+	dest, err := lister(c, "destination")
+	if err != nil {
+		return errors.Wrapf(err, "failed to list %v from %v", resource, c.DestinationHostname)
+	}
+
+	report := diffReport{
+		Resource:    resource,
+		Source:      diffSide{Hostname: c.SourceHostname, Organization: c.SourceOrganizationName},
+		Destination: diffSide{Hostname: c.DestinationHostname, Organization: c.DestinationOrganizationName},
+		Counts:      map[string]int{diffOnlyInSource: 0, diffOnlyInDestination: 0, diffDifferent: 0, diffSame: 0},
+	}
+
+	for _, entry := range compareItems(src, dest, maps) {
+		report.Counts[entry.Status]++
+		if entry.Status == diffSame {
+			continue
+		}
+		if entry.Differences == nil {
+			entry.Differences = diffDifferences{}
+		}
+		l.add(entry.Status, entry.Source, entry.Destination, entry.Differences)
+	}
+
+	l.document = func(records []record) interface{} {
+		report.Entries = records
+		return report
+	}
+
+	if format == formatTable {
+		o.AddFormattedMessageCalculated2("Found %d "+resource+" in source and %d in destination", len(src), len(dest))
+		o.AddDeferredMessageRead("Only in source", report.Counts[diffOnlyInSource])
+		o.AddDeferredMessageRead("Only in destination", report.Counts[diffOnlyInDestination])
+		o.AddDeferredMessageRead("Different", report.Counts[diffDifferent])
+		o.AddDeferredMessageRead("Same", report.Counts[diffSame])
+	}
+
+	return l.write()
+}
@@ -0,0 +1,286 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package list
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/jedib0t/go-pretty/table"
+	"gopkg.in/yaml.v3"
+)
+
+// All functions related to the output formats of the list commands
+
+// The `--format` output formats
+const (
+	formatTable  = "table"
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatYAML   = "yaml"
+	formatNDJSON = "ndjson"
+)
+
+var formats = []string{formatTable, formatJSON, formatCSV, formatYAML, formatNDJSON}
+
+// A field of the schema of a listed resource. Key names the field in the json, csv, yaml and
+// ndjson formats and in `--columns`, Header names the table column.
+type column struct {
+	key    string
+	header string
+}
+
+// A listed object with the values of the selected columns, in column order
+type record struct {
+	columns []column
+	values  []interface{}
+}
+
+// The objects listed by a list command
+type listing struct {
+	resource string
+	columns  []column
+	// The indexes of the columns selected with `--columns`
+	selected []int
+	records  []record
+	// Optional: Returns the json and yaml document of the records. Defaults to an object
+	// with the records under the resource name.
+	document func(records []record) interface{}
+}
+
+// Checks the --format, --json and --output flags. Progress messages are not printed when
+// the json, csv, yaml or ndjson output is printed to stdout.
+func checkFormat() error {
+	if jsonOut {
+		if ListCmd.Flags().Lookup("format").Changed && format != formatJSON {
+			return fmt.Errorf("--json can not be used with --format %v", format)
+		}
+		format = formatJSON
+	}
+
+	valid := false
+	for _, f := range formats {
+		if format == f {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid --format %q, expected one of %v", format, strings.Join(formats, ", "))
+	}
+
+	o.JsonOutput = format != formatTable && outputFile == ""
+	return nil
+}
+
+// Returns a listing of a resource with the columns selected with `--columns`, by default all columns
+func newListing(resource string, schema []column) (*listing, error) {
+	l := &listing{resource: resource, columns: schema}
+
+	if len(columns) == 0 {
+		for i := range schema {
+			l.selected = append(l.selected, i)
+		}
+		return l, nil
+	}
+
+	keys := []string{}
+	for _, c := range schema {
+		keys = append(keys, c.key)
+	}
+
+	for _, name := range columns {
+		index := -1
+		for i, c := range schema {
+			if c.key == strings.TrimSpace(name) {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("invalid column %q for %v, expected one of %v", name, resource, strings.Join(keys, ", "))
+		}
+		l.selected = append(l.selected, index)
+	}
+
+	return l, nil
+}
+
+// Adds an object to the listing with a value for each column of the schema
+func (l *listing) add(values ...interface{}) {
+	r := record{}
+	for _, i := range l.selected {
+		r.columns = append(r.columns, l.columns[i])
+		r.values = append(r.values, values[i])
+	}
+	l.records = append(l.records, r)
+}
+
+// Returns the number of listed objects
+func (l *listing) len() int {
+	return len(l.records)
+}
+
+// Outputs the listing in the `--format` format to stdout, or to the `--output` file
+func (l *listing) write() error {
+	headers := []interface{}{}
+	for _, i := range l.selected {
+		headers = append(headers, l.columns[i].header)
+	}
+
+	// Tables printed to stdout are rendered by Close() with the other messages
+	if format == formatTable && outputFile == "" {
+		o.AddTableHeaders(headers...)
+		for _, r := range l.records {
+			o.AddTableRows(r.cells(true)...)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case formatTable:
+		t := table.NewWriter()
+		t.AppendHeader(headers)
+		for _, r := range l.records {
+			t.AppendRow(r.cells(true))
+		}
+		t.SetStyle(table.StyleRounded)
+		buf.WriteString(t.Render() + "\n")
+
+	case formatJSON:
+		data, err := json.Marshal(l.getDocument())
+		if err != nil {
+			return fmt.Errorf("failed to marshal %v to JSON: %v", l.resource, err)
+		}
+		buf.Write(data)
+		buf.WriteString("\n")
+
+	case formatNDJSON:
+		for _, r := range l.records {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %v to JSON: %v", l.resource, err)
+			}
+			buf.Write(data)
+			buf.WriteString("\n")
+		}
+
+	case formatCSV:
+		w := csv.NewWriter(&buf)
+		keys := []string{}
+		for _, i := range l.selected {
+			keys = append(keys, l.columns[i].key)
+		}
+		w.Write(keys)
+		for _, r := range l.records {
+			row := []string{}
+			for _, cell := range r.cells(false) {
+				row = append(row, fmt.Sprint(cell))
+			}
+			w.Write(row)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return fmt.Errorf("failed to write %v as CSV: %v", l.resource, err)
+		}
+
+	case formatYAML:
+		e := yaml.NewEncoder(&buf)
+		e.SetIndent(2)
+		if err := e.Encode(l.getDocument()); err != nil {
+			return fmt.Errorf("failed to marshal %v to YAML: %v", l.resource, err)
+		}
+		e.Close()
+	}
+
+	if outputFile == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	if err := os.WriteFile(outputFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %v: %v", outputFile, err)
+	}
+	o.AddFormattedMessageCalculated2("Wrote %d "+l.resource+" to %v", l.len(), outputFile)
+
+	return nil
+}
+
+// Returns the json and yaml document of the listing
+func (l *listing) getDocument() interface{} {
+	records := l.records
+	if records == nil {
+		records = []record{}
+	}
+
+	if l.document != nil {
+		return l.document(records)
+	}
+	return map[string]interface{}{l.resource: records}
+}
+
+// Returns the values of a record as table or CSV cells. Times are formatted as RFC 3339 in CSV.
+func (r record) cells(table bool) []interface{} {
+	cells := []interface{}{}
+	for _, v := range r.values {
+		switch value := v.(type) {
+		case nil:
+			cells = append(cells, "")
+		case time.Time:
+			if table {
+				cells = append(cells, helper.FormatDateTime(value))
+			} else {
+				cells = append(cells, value.Format(time.RFC3339))
+			}
+		case fmt.Stringer:
+			cells = append(cells, value.String())
+		default:
+			cells = append(cells, value)
+		}
+	}
+	return cells
+}
+
+// Marshals a record as a JSON object with the keys in column order
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, c := range r.columns {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(c.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// Marshals a record as a YAML mapping with the keys in column order
+func (r record) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i, c := range r.columns {
+		value := &yaml.Node{}
+		if err := value.Encode(r.values[i]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: c.key}, value)
+	}
+	return node, nil
+}
@@ -4,12 +4,20 @@
 package list
 
 import (
+	"context"
+
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
 )
 
 var (
-	side    string
-	jsonOut bool
+	side       string
+	jsonOut    bool
+	diff       bool
+	format     string
+	columns    []string
+	outputFile string
 
 	ListCmd = &cobra.Command{
 		Use:   "list",
@@ -20,6 +28,34 @@ var (
 
 func init() {
 
-	ListCmd.PersistentFlags().StringVar(&side, "side", "", "Specify source or destination side to process")
-	ListCmd.PersistentFlags().BoolVar(&jsonOut, "json", false, "Print the output in JSON format. Only supported with [workspaces, projects]")
+	ListCmd.PersistentFlags().StringVar(&side, "side", "", "Specify source or destination side to process, or both with --diff")
+	ListCmd.PersistentFlags().BoolVar(&diff, "diff", false, "Compare the source and destination sides. Must be used with --side both")
+	ListCmd.PersistentFlags().BoolVar(&jsonOut, "json", false, "Print the output in JSON format, the same as --format json")
+	ListCmd.PersistentFlags().StringVar(&format, "format", formatTable, "Output format: table, json, csv, yaml or ndjson")
+	ListCmd.PersistentFlags().StringSliceVar(&columns, "columns", nil, "Comma separated list of the columns to output, in order. Defaults to all columns")
+	ListCmd.PersistentFlags().StringVar(&outputFile, "output", "", "Write the output to a file instead of stdout")
+}
+
+// Returns the side to list, the source if no side is specified
+func getListSide() string {
+	if side == "destination" {
+		return "destination"
+	}
+	return "source"
+}
+
+// Returns the client, context and organization of a side
+func getSideClient(c tfclient.ClientContexts, side string) (*tfe.Client, context.Context, string) {
+	if side == "destination" {
+		return c.DestinationClient, c.DestinationContext, c.DestinationOrganizationName
+	}
+	return c.SourceClient, c.SourceContext, c.SourceOrganizationName
+}
+
+// Returns the hostname of a side
+func getSideHostname(c tfclient.ClientContexts, side string) string {
+	if side == "destination" {
+		return c.DestinationHostname
+	}
+	return c.SourceHostname
 }
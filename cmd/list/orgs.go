@@ -40,8 +40,18 @@ var (
 		Aliases: []string{"orgs"},
 		Short:   "List Organizations",
 		Long:    "List of Organizations.",
-		Run: func(cmd *cobra.Command, args []string) {
-			orgList(tfclient.GetClientContexts())
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return orgListDiff(tfclient.GetClientContexts())
+			}
+			return orgList(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -82,7 +92,21 @@ func init() {
 
 }
 
+// The schema of `tfm list organization`
+var orgColumns = []column{
+	{"name", "Name"},
+	{"createdAt", "Created On"},
+	{"email", "Email"},
+}
+
 func orgList(c tfclient.ClientContexts) error {
+	listSide := getListSide()
+	client, ctx, _ := getSideClient(c, listSide)
+
+	l, err := newListing("organizations", orgColumns)
+	if err != nil {
+		return err
+	}
 
 	allItems := []*tfe.Organization{}
 	opts := tfe.OrganizationListOptions{
@@ -91,62 +115,76 @@ func orgList(c tfclient.ClientContexts) error {
 			PageSize:   100},
 	}
 
-	if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
+	o.AddMessageUserProvided("List of Organizations at: ", getSideHostname(c, listSide))
 
-		o.AddMessageUserProvided("List of Organizations at: ", c.SourceHostname)
-
-		for {
-			items, err := c.SourceClient.Organizations.List(c.SourceContext, &opts)
-			if err != nil {
-				helper.LogError(err, "failed to list orgs")
-			}
-
-			allItems = append(allItems, items.Items...)
-
-			o.AddFormattedMessageCalculated("Found %d Organizations", len(allItems))
-
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+	for {
+		items, err := client.Organizations.List(ctx, &opts)
+		if err != nil {
+			helper.LogError(err, "failed to list orgs")
 		}
 
-		o.AddTableHeaders("Name", "Created On", "Email")
-		for _, i := range allItems {
-			cr_created_at := helper.FormatDateTime(i.CreatedAt)
+		allItems = append(allItems, items.Items...)
+
+		o.AddFormattedMessageCalculated("Found %d Organizations", len(allItems))
 
-			o.AddTableRows(i.Name, cr_created_at, i.Email)
+		if items.CurrentPage >= items.TotalPages {
+			break
 		}
+		opts.PageNumber = items.NextPage
 	}
-	if ListCmd.Flags().Lookup("side").Value.String() == "destination" {
 
-		o.AddMessageUserProvided("List of Organizations at: ", c.DestinationHostname)
+	for _, i := range allItems {
+		l.add(i.Name, i.CreatedAt, i.Email)
+	}
 
-		for {
-			items, err := c.DestinationClient.Organizations.List(c.DestinationContext, &opts)
-			if err != nil {
-				helper.LogError(err, "failed to list orgs")
-			}
+	return l.write()
+}
 
-			allItems = append(allItems, items.Items...)
+// Lists the organizations of a side to compare them
+func orgDiffItems(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+	client, ctx, _ := getSideClient(c, side)
 
-			o.AddFormattedMessageCalculated("Found %d Organizations", len(allItems))
+	items := []diffItem{}
 
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+	opts := tfe.OrganizationListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100},
+	}
+	for {
+		orgs, err := client.Organizations.List(ctx, &opts)
+		if err != nil {
+			return nil, err
 		}
 
-		o.AddTableHeaders("Name", "Created On", "Email")
-		for _, i := range allItems {
-			cr_created_at := helper.FormatDateTime(i.CreatedAt)
+		for _, org := range orgs.Items {
+			items = append(items, diffItem{
+				Name: org.Name,
+				ID:   org.ExternalID,
+				Attributes: map[string]string{
+					"email": org.Email,
+				},
+			})
+		}
 
-			o.AddTableRows(i.Name, cr_created_at, i.Email)
+		if orgs.CurrentPage >= orgs.TotalPages {
+			break
 		}
+		opts.PageNumber = orgs.NextPage
 	}
 
-	return nil
+	return items, nil
+}
+
+// Compares the organizations of the source and destination sides. The source organization
+// is matched with the destination organization.
+func orgListDiff(c tfclient.ClientContexts) error {
+	maps := diffMaps{
+		names: map[string]string{c.SourceOrganizationName: c.DestinationOrganizationName},
+		ids:   map[string]string{},
+	}
+
+	return listDiff(c, "organizations", orgDiffItems, maps)
 }
 
 func orgShow(name string) error {
@@ -5,9 +5,6 @@ package list
 
 import (
 	"context"
-	"fmt"
-
-	"encoding/json"
 
 	"github.com/hashicorp-services/tfm/tfclient"
 	tfe "github.com/hashicorp/go-tfe"
@@ -22,8 +19,18 @@ var (
 		Aliases: []string{"prj"},
 		Short:   "Projects command",
 		Long:    "List Projects in an org",
-		Run: func(cmd *cobra.Command, args []string) {
-			listProjects(tfclient.GetClientContexts(), jsonOut)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return listProjectsDiff(tfclient.GetClientContexts())
+			}
+			return listProjects(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -38,135 +45,106 @@ func init() {
 
 }
 
-func listProjects(c tfclient.ClientContexts, jsonOut bool) error {
+// The schema of `tfm list projects`
+var projectColumns = []column{
+	{"name", "Name"},
+	{"id", "ID"},
+	{"description", "Description"},
+}
 
-	srcProjects := []*tfe.Project{}
-	projectJSON := make(map[string]interface{}) // Parent JSON object "project-names"
-	projectNamesAndIDs := []map[string]string{} // project names slice to go inside parent object "project-names"
+func listProjects(c tfclient.ClientContexts) error {
+	listSide := getListSide()
+	client, ctx, org := getSideClient(c, listSide)
 
-	opts := tfe.ProjectListOptions{
-		ListOptions: tfe.ListOptions{
-			PageNumber: 1,
-			PageSize:   100},
+	l, err := newListing("projects", projectColumns)
+	if err != nil {
+		return err
 	}
 
-	if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
-
-		if jsonOut == false {
-			o.AddMessageUserProvided("Getting list of projects from: ", c.SourceHostname)
-		}
-
-		for {
-			items, err := c.SourceClient.Projects.List(c.SourceContext, c.SourceOrganizationName, &opts)
-			if err != nil {
-				return err
-			}
-
-			srcProjects = append(srcProjects, items.Items...)
+	o.AddMessageUserProvided("Getting list of projects from: ", getSideHostname(c, listSide))
 
-			if jsonOut == false {
-				o.AddFormattedMessageCalculated("Found %d Projects", len(srcProjects))
-			}
+	projects, err := getProjects(client, ctx, org)
+	if err != nil {
+		return err
+	}
 
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+	o.AddFormattedMessageCalculated("Found %d Projects", len(projects))
 
-		}
-		if jsonOut == false {
-			o.AddTableHeaders("Name", "ID")
-		}
-		for _, i := range srcProjects {
-			projectInfo := map[string]string{
-				"name": i.Name,
-				"id":   i.ID,
-			}
+	for _, i := range projects {
+		l.add(i.Name, i.ID, i.Description)
+	}
 
-			if jsonOut {
-				projectNamesAndIDs = append(projectNamesAndIDs, projectInfo) // Store project name in slice
-			}
-			if jsonOut == false {
-				o.AddTableRows(i.Name, i.ID)
-			}
-		}
-		if jsonOut {
-			projectJSON["projects"] = projectNamesAndIDs // Assign projects to the "projects" key
+	return l.write()
+}
 
-			jsonData, err := json.Marshal(projectJSON)
-			if err != nil {
-				fmt.Println("Error marshaling projects to JSON:", err)
-				return err
-			}
+// Lists the projects of an organization
+func getProjects(client *tfe.Client, ctx context.Context, org string) ([]*tfe.Project, error) {
+	projects := []*tfe.Project{}
 
-			fmt.Println(string(jsonData))
-		}
+	opts := tfe.ProjectListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100},
 	}
-
-	if ListCmd.Flags().Lookup("side").Value.String() == "destination" {
-		if jsonOut == false {
-			o.AddMessageUserProvided("Getting list of projects from: ", c.DestinationHostname)
+	for {
+		items, err := client.Projects.List(ctx, org, &opts)
+		if err != nil {
+			return nil, err
 		}
 
-		for {
-			items, err := c.DestinationClient.Projects.List(c.DestinationContext, c.DestinationOrganizationName, &opts)
-			if err != nil {
-				return err
-			}
-
-			srcProjects = append(srcProjects, items.Items...)
-
-			if jsonOut == false {
-				o.AddFormattedMessageCalculated("Found %d Projects", len(srcProjects))
-			}
-
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+		projects = append(projects, items.Items...)
 
+		if items.CurrentPage >= items.TotalPages {
+			break
 		}
-		if jsonOut == false {
-			o.AddTableHeaders("Name", "ID")
-		}
+		opts.PageNumber = items.NextPage
+	}
 
-		for _, i := range srcProjects {
-			projectInfo := map[string]string{
-				"name": i.Name,
-				"id":   i.ID,
-			}
+	return projects, nil
+}
 
-			if jsonOut {
-				projectNamesAndIDs = append(projectNamesAndIDs, projectInfo) // Store project name in slice
-			}
+// Returns the names of the projects of an organization by project ID
+func getProjectNames(client *tfe.Client, ctx context.Context, org string) (map[string]string, error) {
+	projects, err := getProjects(client, ctx, org)
+	if err != nil {
+		return nil, err
+	}
 
-			if jsonOut == false {
-				o.AddTableRows(i.Name, i.ID)
-			}
-		}
-		if jsonOut {
-			projectJSON["projects"] = projectNamesAndIDs // Assign projects to the "project" key
+	names := map[string]string{}
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+	return names, nil
+}
 
-			jsonData, err := json.Marshal(projectJSON)
-			if err != nil {
-				fmt.Println("Error marshaling projects to JSON:", err)
-				return err
-			}
+// Lists the projects of a side to compare them
+func projectDiffItems(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+	client, ctx, org := getSideClient(c, side)
 
-			fmt.Println(string(jsonData))
-		}
+	projects, err := getProjects(client, ctx, org)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	items := []diffItem{}
+	for _, p := range projects {
+		items = append(items, diffItem{
+			Name: p.Name,
+			ID:   p.ID,
+			Attributes: map[string]string{
+				"description": p.Description,
+			},
+		})
+	}
+	return items, nil
 }
 
-func getProjectName(client *tfe.Client, ctx context.Context, projectId string) (string, error) {
-
-	prj, err := client.Projects.Read(ctx, projectId)
-
+// Compares the projects of the source and destination sides with `projects-map`
+func listProjectsDiff(c tfclient.ClientContexts) error {
+	maps, err := getDiffMaps("projects-map", "")
 	if err != nil {
-		return "error reading project", err
+		return err
 	}
 
-	return prj.Name, nil
+	return listDiff(c, "projects", projectDiffItems, maps)
 }
@@ -4,8 +4,6 @@
 package list
 
 import (
-	"fmt"
-
 	"github.com/hashicorp-services/tfm/tfclient"
 	tfe "github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
@@ -23,10 +21,20 @@ var (
 		// 		tfeclient.GetClientContexts())
 
 		// },
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			// return orgShow(
 			// 	viper.GetString("name"))
-			listSrcSSHKeys(tfclient.GetClientContexts())
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return listSSHKeysDiff(tfclient.GetClientContexts())
+			}
+			return listSrcSSHKeys(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -45,7 +53,20 @@ func init() {
 
 }
 
+// The schema of `tfm list ssh`
+var sshKeyColumns = []column{
+	{"name", "Key Name"},
+	{"id", "Key ID"},
+}
+
 func listSrcSSHKeys(c tfclient.ClientContexts) error {
+	listSide := getListSide()
+	client, ctx, org := getSideClient(c, listSide)
+
+	l, err := newListing("ssh-keys", sshKeyColumns)
+	if err != nil {
+		return err
+	}
 
 	keys := []*tfe.SSHKey{}
 
@@ -56,63 +77,68 @@ func listSrcSSHKeys(c tfclient.ClientContexts) error {
 		},
 	}
 
-	if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
-
-		o.AddMessageUserProvided("Getting list of SSH keys from: ", c.SourceHostname)
-
-		for {
-			k, err := c.SourceClient.SSHKeys.List(c.SourceContext, c.SourceOrganizationName, &opts)
-			if err != nil {
-				return err
-			}
-
-			fmt.Println()
-			keys = append(keys, k.Items...)
-
-			o.AddFormattedMessageCalculated("Found %d SSH keys", len(keys))
-
-			if k.CurrentPage >= k.TotalPages {
-				break
-			}
-			opts.PageNumber = k.NextPage
+	o.AddMessageUserProvided("Getting list of SSH keys from: ", getSideHostname(c, listSide))
 
+	for {
+		k, err := client.SSHKeys.List(ctx, org, &opts)
+		if err != nil {
+			return err
 		}
-		o.AddTableHeaders("Key Name", "Key ID")
-		for _, i := range keys {
 
-			o.AddTableRows(i.Name, i.ID)
+		keys = append(keys, k.Items...)
+
+		o.AddFormattedMessageCalculated("Found %d SSH keys", len(keys))
 
+		if k.CurrentPage >= k.TotalPages {
+			break
 		}
+		opts.PageNumber = k.NextPage
 	}
 
-	if ListCmd.Flags().Lookup("side").Value.String() == "destination" {
+	for _, i := range keys {
+		l.add(i.Name, i.ID)
+	}
 
-		o.AddMessageUserProvided("Getting list of SSH keys from: ", c.DestinationHostname)
+	return l.write()
+}
 
-		for {
-			k, err := c.DestinationClient.SSHKeys.List(c.DestinationContext, c.DestinationOrganizationName, &opts)
-			if err != nil {
-				return err
-			}
+// Lists the SSH keys of a side to compare them. The key values can not be read, so only names are compared.
+func sshKeyDiffItems(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+	client, ctx, org := getSideClient(c, side)
 
-			fmt.Println()
-			keys = append(keys, k.Items...)
+	items := []diffItem{}
 
-			o.AddFormattedMessageCalculated("Found %d SSH keys", len(keys))
+	opts := tfe.SSHKeyListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100,
+		},
+	}
+	for {
+		keys, err := client.SSHKeys.List(ctx, org, &opts)
+		if err != nil {
+			return nil, err
+		}
 
-			if k.CurrentPage >= k.TotalPages {
-				break
-			}
-			opts.PageNumber = k.NextPage
+		for _, k := range keys.Items {
+			items = append(items, diffItem{Name: k.Name, ID: k.ID})
+		}
 
+		if keys.CurrentPage >= keys.TotalPages {
+			break
 		}
-		o.AddTableHeaders("Key Name", "Key ID")
-		for _, i := range keys {
+		opts.PageNumber = keys.NextPage
+	}
 
-			o.AddTableRows(i.Name, i.ID)
+	return items, nil
+}
 
-		}
+// Compares the SSH keys of the source and destination sides with `ssh-map`
+func listSSHKeysDiff(c tfclient.ClientContexts) error {
+	maps, err := getDiffMaps("", "ssh-map")
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return listDiff(c, "ssh-keys", sshKeyDiffItems, maps)
 }
@@ -0,0 +1,277 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package list
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stateVersions bool
+
+	// `tfm list states` command
+	statesListCmd = &cobra.Command{
+		Use:   "states",
+		Short: "States command",
+		Long:  "List the state inventory of workspaces in an org, with the state history and size of each workspace",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return errors.New("--diff is not supported with states")
+			}
+			return listStates(tfclient.GetClientContexts(), stateVersions)
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	statesListCmd.Flags().BoolVar(&stateVersions, "versions", false, "List every state version of each workspace instead of the current state")
+
+	// Add commands
+	ListCmd.AddCommand(statesListCmd)
+}
+
+// The schema of `tfm list states`, the current state of each workspace
+var stateColumns = []column{
+	{"workspace", "Workspace"},
+	{"versions", "Versions"},
+	{"serial", "Serial"},
+	{"lineage", "Lineage"},
+	{"terraformVersion", "TF Version"},
+	{"size", "Size (Bytes)"},
+	{"resources", "Resources"},
+	{"lastUpdated", "Last Updated"},
+}
+
+// The schema of `tfm list states --versions`, every state version of each workspace
+var stateVersionColumns = []column{
+	{"workspace", "Workspace"},
+	{"id", "ID"},
+	{"serial", "Serial"},
+	{"lineage", "Lineage"},
+	{"terraformVersion", "TF Version"},
+	{"size", "Size (Bytes)"},
+	{"resources", "Resources"},
+	{"createdAt", "Created At"},
+	{"current", "Current"},
+}
+
+// The totals of the listed states. Size is the size of the current states, or of all state
+// versions with --versions.
+type stateTotals struct {
+	Workspaces    int   `json:"workspaces" yaml:"workspaces"`
+	StateVersions int   `json:"stateVersions" yaml:"stateVersions"`
+	Size          int64 `json:"size" yaml:"size"`
+	Resources     int   `json:"resources" yaml:"resources"`
+}
+
+// The size and top level attributes of a downloaded state version
+type stateInfo struct {
+	size  int64
+	state *tfstate.State
+}
+
+// Returns the number of state versions of a workspace
+func countStateVersions(client *tfe.Client, ctx context.Context, org string, ws *tfe.Workspace) (int, error) {
+	opts := tfe.StateVersionListOptions{
+		ListOptions:  tfe.ListOptions{PageNumber: 1, PageSize: 1},
+		Organization: org,
+		Workspace:    ws.Name,
+	}
+
+	items, err := client.StateVersions.List(ctx, &opts)
+	if err != nil {
+		return 0, err
+	}
+	if items.Pagination == nil {
+		return len(items.Items), nil
+	}
+	return items.TotalCount, nil
+}
+
+// Lists all state versions of a workspace, newest first
+func getStateVersions(client *tfe.Client, ctx context.Context, org string, ws *tfe.Workspace) ([]*tfe.StateVersion, error) {
+	states := []*tfe.StateVersion{}
+
+	opts := tfe.StateVersionListOptions{
+		ListOptions:  tfe.ListOptions{PageNumber: 1, PageSize: 100},
+		Organization: org,
+		Workspace:    ws.Name,
+	}
+	for {
+		items, err := client.StateVersions.List(ctx, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		states = append(states, items.Items...)
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return states, nil
+}
+
+// Downloads a state version to a temp file to read its size and top level attributes
+func inspectStateVersion(client *tfe.Client, ctx context.Context, sv *tfe.StateVersion) (*stateInfo, error) {
+	f, err := tfstate.Download(ctx, client, sv.DownloadURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download state version %v", sv.ID)
+	}
+	defer f.Remove()
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	state, err := tfstate.ParseReader(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse state version %v", sv.ID)
+	}
+
+	return &stateInfo{size: f.Size, state: state}, nil
+}
+
+// Formats a size in bytes with a binary unit
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// Adds the current state of a workspace to the listing
+func addCurrentState(l *listing, totals *stateTotals, client *tfe.Client, ctx context.Context, org string, ws *tfe.Workspace) error {
+	versions, err := countStateVersions(client, ctx, org, ws)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list the states of workspace "+ws.Name)
+	}
+	totals.StateVersions += versions
+
+	current, err := client.StateVersions.ReadCurrent(ctx, ws.ID)
+	if err == tfe.ErrResourceNotFound {
+		l.add(ws.Name, versions, nil, "", "", nil, nil, nil)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to read the current state of workspace "+ws.Name)
+	}
+
+	info, err := inspectStateVersion(client, ctx, current)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read the current state of workspace "+ws.Name)
+	}
+	totals.Size += info.size
+	totals.Resources += info.state.Resources
+
+	l.add(ws.Name, versions, info.state.Serial, info.state.Lineage, info.state.TerraformVersion, info.size, info.state.Resources, current.CreatedAt)
+	return nil
+}
+
+// Adds every state version of a workspace to the listing
+func addStateVersions(l *listing, totals *stateTotals, client *tfe.Client, ctx context.Context, org string, ws *tfe.Workspace) error {
+	states, err := getStateVersions(client, ctx, org, ws)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list the states of workspace "+ws.Name)
+	}
+	totals.StateVersions += len(states)
+
+	// Versions are listed newest first, the first is the current state
+	for i, sv := range states {
+		info, err := inspectStateVersion(client, ctx, sv)
+		if err != nil {
+			return errors.Wrap(err, "Failed to read the states of workspace "+ws.Name)
+		}
+		totals.Size += info.size
+		if i == 0 {
+			totals.Resources += info.state.Resources
+		}
+
+		l.add(ws.Name, sv.ID, info.state.Serial, info.state.Lineage, info.state.TerraformVersion, info.size, info.state.Resources, sv.CreatedAt, i == 0)
+	}
+
+	return nil
+}
+
+// Main function for `tfm list states`
+func listStates(c tfclient.ClientContexts, versions bool) error {
+	listSide := getListSide()
+	client, ctx, org := getSideClient(c, listSide)
+
+	l, err := newListing("states", stateColumns)
+	if versions {
+		l, err = newListing("state-versions", stateVersionColumns)
+	}
+	if err != nil {
+		return err
+	}
+
+	o.AddMessageUserProvided("Getting list of states from: ", getSideHostname(c, listSide))
+
+	workspaces, err := helper.GetWorkspacesCfg(client, ctx, org, listSide)
+	if err != nil {
+		return err
+	}
+
+	o.AddFormattedMessageCalculated("Found %d Workspaces", len(workspaces))
+
+	totals := &stateTotals{Workspaces: len(workspaces)}
+	for _, ws := range workspaces {
+		if versions {
+			err = addStateVersions(l, totals, client, ctx, org, ws)
+		} else {
+			err = addCurrentState(l, totals, client, ctx, org, ws)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	l.document = func(records []record) interface{} {
+		return map[string]interface{}{l.resource: records, "totals": totals}
+	}
+
+	if format == formatTable {
+		o.AddDeferredMessageRead("Workspaces", totals.Workspaces)
+		o.AddDeferredMessageRead("State versions", totals.StateVersions)
+		o.AddDeferredMessageRead("Resources in current states", totals.Resources)
+		if versions {
+			o.AddDeferredMessageRead("Size of all state versions", formatSize(totals.Size))
+		} else {
+			o.AddDeferredMessageRead("Size of current states", formatSize(totals.Size))
+		}
+	}
+
+	return l.write()
+}
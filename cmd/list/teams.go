@@ -4,6 +4,10 @@
 package list
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/hashicorp-services/tfm/tfclient"
 	"github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
@@ -21,10 +25,20 @@ var (
 		// 		tfeclient.GetClientContexts())
 
 		// },
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			// return orgShow(
 			// 	viper.GetString("name"))
-			listTeams(tfclient.GetClientContexts())
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return listTeamsDiff(tfclient.GetClientContexts())
+			}
+			return listTeams(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -43,7 +57,22 @@ func init() {
 
 }
 
+// The schema of `tfm list teams`
+var teamColumns = []column{
+	{"name", "Name"},
+	{"id", "ID"},
+	{"visibility", "Visibility"},
+	{"usersCount", "Users"},
+}
+
 func listTeams(c tfclient.ClientContexts) error {
+	listSide := getListSide()
+	client, ctx, org := getSideClient(c, listSide)
+
+	l, err := newListing("teams", teamColumns)
+	if err != nil {
+		return err
+	}
 
 	srcTeams := []*tfe.Team{}
 
@@ -53,58 +82,108 @@ func listTeams(c tfclient.ClientContexts) error {
 			PageSize:   100},
 	}
 
-	if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
+	o.AddMessageUserProvided("Getting list of teams from: ", getSideHostname(c, listSide))
 
-		o.AddMessageUserProvided("Getting list of teams from: ", c.SourceHostname)
-
-		for {
-			items, err := c.SourceClient.Teams.List(c.SourceContext, c.SourceOrganizationName, &opts)
-			if err != nil {
-				return err
-			}
-
-			srcTeams = append(srcTeams, items.Items...)
+	for {
+		items, err := client.Teams.List(ctx, org, &opts)
+		if err != nil {
+			return err
+		}
 
-			o.AddFormattedMessageCalculated("Found %d Teams", len(srcTeams))
+		srcTeams = append(srcTeams, items.Items...)
 
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+		o.AddFormattedMessageCalculated("Found %d Teams", len(srcTeams))
 
+		if items.CurrentPage >= items.TotalPages {
+			break
 		}
-		o.AddTableHeaders("Name")
-		for _, i := range srcTeams {
+		opts.PageNumber = items.NextPage
+	}
 
-			o.AddTableRows(i.Name)
-		}
+	for _, i := range srcTeams {
+		l.add(i.Name, i.ID, i.Visibility, i.UserCount)
 	}
 
-	if ListCmd.Flags().Lookup("side").Value.String() == "destination" {
-		o.AddMessageUserProvided("Getting list of teams from: ", c.DestinationHostname)
+	return l.write()
+}
 
-		for {
-			items, err := c.DestinationClient.Teams.List(c.DestinationContext, c.DestinationOrganizationName, &opts)
-			if err != nil {
-				return err
-			}
+// Lists the teams of a side to compare them
+func teamDiffItems(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+	client, ctx, org := getSideClient(c, side)
 
-			srcTeams = append(srcTeams, items.Items...)
+	items := []diffItem{}
 
-			o.AddFormattedMessageCalculated("Found %d Teams", len(srcTeams))
+	opts := tfe.TeamListOptions{
+		ListOptions: tfe.ListOptions{
+			PageNumber: 1,
+			PageSize:   100},
+	}
+	for {
+		teams, err := client.Teams.List(ctx, org, &opts)
+		if err != nil {
+			return nil, err
+		}
 
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+		for _, t := range teams.Items {
+			items = append(items, diffItem{
+				Name: t.Name,
+				ID:   t.ID,
+				Attributes: map[string]string{
+					"visibility":          t.Visibility,
+					"users-count":         strconv.Itoa(t.UserCount),
+					"organization-access": formatOrganizationAccess(t.OrganizationAccess),
+				},
+			})
+		}
 
+		if teams.CurrentPage >= teams.TotalPages {
+			break
 		}
-		o.AddTableHeaders("Name")
-		for _, i := range srcTeams {
+		opts.PageNumber = teams.NextPage
+	}
+
+	return items, nil
+}
+
+// Formats the organization permissions a team has
+func formatOrganizationAccess(access *tfe.OrganizationAccess) string {
+	if access == nil {
+		return ""
+	}
 
-			o.AddTableRows(i.Name)
+	permissions := []string{}
+	for name, granted := range map[string]bool{
+		"manage-policies":            access.ManagePolicies,
+		"manage-policy-overrides":    access.ManagePolicyOverrides,
+		"manage-workspaces":          access.ManageWorkspaces,
+		"manage-vcs-settings":        access.ManageVCSSettings,
+		"manage-providers":           access.ManageProviders,
+		"manage-modules":             access.ManageModules,
+		"manage-run-tasks":           access.ManageRunTasks,
+		"manage-projects":            access.ManageProjects,
+		"manage-membership":          access.ManageMembership,
+		"read-workspaces":            access.ReadWorkspaces,
+		"read-projects":              access.ReadProjects,
+		"manage-teams":               access.ManageTeams,
+		"manage-organization-access": access.ManageOrganizationAccess,
+		"access-secret-teams":        access.AccessSecretTeams,
+		"manage-agent-pools":         access.ManageAgentPools,
+	} {
+		if granted {
+			permissions = append(permissions, name)
 		}
 	}
+	sort.Strings(permissions)
+
+	return strings.Join(permissions, ",")
+}
+
+// Compares the teams of the source and destination sides with `teams-map`
+func listTeamsDiff(c tfclient.ClientContexts) error {
+	maps, err := getDiffMaps("teams-map", "")
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return listDiff(c, "teams", teamDiffItems, maps)
 }
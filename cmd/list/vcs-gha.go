@@ -16,8 +16,18 @@ var (
 		Aliases: []string{"vcs-gha"},
 		Short:   "List GHA VCS Providers",
 		Long:    "List of GitHub App VCS Providers. Will default to source if no side is specified",
-		Run: func(cmd *cobra.Command, args []string) {
-			ghaVcsList(tfclient.GetClientContexts())
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return ghaVcsListDiff(tfclient.GetClientContexts())
+			}
+			return ghaVcsList(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -61,12 +71,24 @@ func ghaVcsListAllForOrganization(c tfclient.ClientContexts) ([]*tfe.GHAInstalla
 	return allGHAItems, nil
 }
 
+// The schema of `tfm list vcs-gha`. The ID and Installation ID table headers are flipped as they
+// are flipped in the TFE/HCP TF UI, so we are matching the UI instead of the API/SDK
+var ghaVcsColumns = []column{
+	{"name", "Name"},
+	{"id", "Installation ID"},
+	{"installationId", "ID"},
+}
+
 // output functions
 func ghaVcsList(c tfclient.ClientContexts) error {
+	l, err := newListing("vcs-gha", ghaVcsColumns)
+	if err != nil {
+		return err
+	}
+
 	o.AddMessageUserProvided("List vcs for configured Organizations", "")
 
 	var orgGhaVcsList []*tfe.GHAInstallation
-	var err error
 
 	if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
 		orgGhaVcsList, err = ghaVcsListAllForOrganization(c)
@@ -82,12 +104,54 @@ func ghaVcsList(c tfclient.ClientContexts) error {
 
 	o.AddFormattedMessageCalculated("Found %d vcs", len(orgGhaVcsList))
 
-	o.AddTableHeaders("Name", "Installation ID", "ID")
 	for _, i := range orgGhaVcsList {
+		l.add(*i.Name, *i.ID, *i.InstallationID)
+	}
+
+	return l.write()
+}
+
+// Lists the GitHub App installations of a side to compare them
+func ghaVcsDiffItems(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+	client, ctx, _ := getSideClient(c, side)
+
+	items := []diffItem{}
+
+	opts := tfe.GHAInstallationListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		installations, err := client.GHAInstallations.List(ctx, &opts)
+		if err != nil {
+			return nil, err
+		}
 
-		// The ID and Installation ID are flipped as they are flipped in the TFE/HCP TF UI, so we are matching the UI instead of the API/SDK
-		o.AddTableRows(*i.Name, *i.ID, *i.InstallationID) 
+		for _, i := range installations.Items {
+			name, id := "", ""
+			if i.Name != nil {
+				name = *i.Name
+			}
+			if i.ID != nil {
+				id = *i.ID
+			}
+			items = append(items, diffItem{Name: name, ID: id})
+		}
+
+		if installations.CurrentPage >= installations.TotalPages {
+			break
+		}
+		opts.PageNumber = installations.NextPage
+	}
+
+	return items, nil
+}
+
+// Compares the GitHub App installations of the source and destination sides with `vcs-map`
+func ghaVcsListDiff(c tfclient.ClientContexts) error {
+	maps, err := getDiffMaps("", "vcs-map")
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return listDiff(c, "vcs-gha", ghaVcsDiffItems, maps)
 }
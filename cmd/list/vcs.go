@@ -4,6 +4,8 @@
 package list
 
 import (
+	"fmt"
+
 	"github.com/hashicorp-services/tfm/cmd/helper"
 	"github.com/hashicorp-services/tfm/tfclient"
 	tfe "github.com/hashicorp/go-tfe"
@@ -18,12 +20,21 @@ var (
 		Aliases: []string{"vcs"},
 		Short:   "List VCS Providers",
 		Long:    "List of VCS Providers. Will default to source if no side is specified",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return vcsListDiff(tfclient.GetClientContexts())
+			}
 			if all {
-				vcsListAll(tfclient.GetClientContexts())
-			} else {
-				vcsList(tfclient.GetClientContexts())
+				return vcsListAll(tfclient.GetClientContexts())
 			}
+			return vcsList(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -113,8 +124,46 @@ func organizationListAll(c tfclient.ClientContexts) ([]*tfe.Organization, error)
 	return allItems, nil
 }
 
+// The schema of `tfm list vcs`
+var vcsColumns = []column{
+	{"organization", "Organization"},
+	{"name", "Name"},
+	{"id", "Id"},
+	{"serviceProvider", "Service Provider"},
+	{"serviceProviderName", "Service Provider Name"},
+	{"createdAt", "Created At"},
+	{"url", "URL"},
+}
+
+// Adds VCS providers to a listing. The ID is the ID of the first OAuth token of the provider.
+func addVCSRows(l *listing, vcsList []*tfe.OAuthClient) {
+	for _, i := range vcsList {
+		vcsName := ""
+		if i.Name != nil {
+			vcsName = *i.Name
+		}
+
+		OAuthTokenID := ""
+		if len(i.OAuthTokens) > 0 {
+			OAuthTokenID = i.OAuthTokens[0].ID
+		}
+
+		orgName := ""
+		if i.Organization != nil {
+			orgName = i.Organization.Name
+		}
+
+		l.add(orgName, vcsName, OAuthTokenID, i.ServiceProvider, i.ServiceProviderName, i.CreatedAt, i.HTTPURL)
+	}
+}
+
 // output functions
 func vcsListAll(c tfclient.ClientContexts) error {
+	l, err := newListing("vcs", vcsColumns)
+	if err != nil {
+		return err
+	}
+
 	o.AddMessageUserProvided("List vcs for all available Organizations", "")
 
 	allOrgs, err := organizationListAll(c)
@@ -135,30 +184,20 @@ func vcsListAll(c tfclient.ClientContexts) error {
 
 	o.AddFormattedMessageCalculated("Found %d vcs", len(allVcsList))
 
-	o.AddTableHeaders("Organization", "Name", "Id", "Service Provider", "Service Provider Name", "Created At", "URL")
-	for _, i := range allVcsList {
+	addVCSRows(l, allVcsList)
 
-		vcsName := "No Name"
-		if i.Name != nil {
-			vcsName = *i.Name
-		}
-
-		OAuthTokenID := "No OAuthToken"
-		if i.OAuthTokens != nil {
-			OAuthTokenID = i.OAuthTokens[0].ID
-		}
-
-		o.AddTableRows(i.Organization.Name, vcsName, OAuthTokenID, i.ServiceProvider, i.ServiceProviderName, i.CreatedAt, i.HTTPURL)
-	}
-
-	return nil
+	return l.write()
 }
 
 func vcsList(c tfclient.ClientContexts) error {
+	l, err := newListing("vcs", vcsColumns)
+	if err != nil {
+		return err
+	}
+
 	o.AddMessageUserProvided("List vcs for configured Organizations", "")
 
 	var orgVcsList []*tfe.OAuthClient
-	var err error
 
 	if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
 		orgVcsList, err = vcsListAllForOrganization(c, c.SourceOrganizationName)
@@ -174,21 +213,65 @@ func vcsList(c tfclient.ClientContexts) error {
 
 	o.AddFormattedMessageCalculated("Found %d vcs", len(orgVcsList))
 
-	o.AddTableHeaders("Organization", "Name", "Id", "Service Provider", "Service Provider Name", "Created At", "URL")
-	for _, i := range orgVcsList {
+	addVCSRows(l, orgVcsList)
 
-		vcsName := "No Name"
-		if i.Name != nil {
-			vcsName = *i.Name
+	return l.write()
+}
+
+// Lists the VCS providers of a side to compare them. Providers without a name are named
+// after their service provider and URL.
+func vcsDiffItems(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+	client, ctx, org := getSideClient(c, side)
+
+	items := []diffItem{}
+
+	opts := tfe.OAuthClientListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		clients, err := client.OAuthClients.List(ctx, org, &opts)
+		if err != nil {
+			return nil, err
 		}
 
-		OAuthTokenID := "No OAuthToken"
-		if i.OAuthTokens != nil {
-			OAuthTokenID = i.OAuthTokens[0].ID
+		for _, i := range clients.Items {
+			name := fmt.Sprintf("%v %v", i.ServiceProvider, i.HTTPURL)
+			if i.Name != nil && *i.Name != "" {
+				name = *i.Name
+			}
+
+			// The vcs-map maps OAuth token IDs
+			oauthTokenID := ""
+			if len(i.OAuthTokens) > 0 {
+				oauthTokenID = i.OAuthTokens[0].ID
+			}
+
+			items = append(items, diffItem{
+				Name: name,
+				ID:   oauthTokenID,
+				Attributes: map[string]string{
+					"service-provider": string(i.ServiceProvider),
+					"http-url":         i.HTTPURL,
+					"api-url":          i.APIURL,
+				},
+			})
+		}
+
+		if clients.CurrentPage >= clients.TotalPages {
+			break
 		}
+		opts.PageNumber = clients.NextPage
+	}
 
-		o.AddTableRows(i.Organization.Name, vcsName, OAuthTokenID, i.ServiceProvider, i.ServiceProviderName, i.CreatedAt, i.HTTPURL)
+	return items, nil
+}
+
+// Compares the VCS providers of the source and destination sides with `vcs-map`
+func vcsListDiff(c tfclient.ClientContexts) error {
+	maps, err := getDiffMaps("", "vcs-map")
+	if err != nil {
+		return err
 	}
 
-	return nil
-}
\ No newline at end of file
+	return listDiff(c, "vcs", vcsDiffItems, maps)
+}
@@ -4,8 +4,6 @@
 package list
 
 import (
-	"fmt"
-
 	"github.com/hashicorp-services/tfm/tfclient"
 	tfe "github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
@@ -23,8 +21,18 @@ var (
 		Aliases: []string{"workspace-filter"},
 		Short:   "Filter workspaces",
 		Long:    "Filter Workspaces. Trying different ways to return workspaces",
-		Run: func(cmd *cobra.Command, args []string) {
-			workspaceFilter(tfclient.GetClientContexts())
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return workspaceFilterDiff(tfclient.GetClientContexts())
+			}
+			return workspaceFilter(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -41,17 +49,15 @@ func init() {
 	workspaceFilterCmd.PersistentFlags().StringSliceVar(&includes, "includes", nil, "Additional relations to include, comma separated, no space")
 }
 
-func workspaceFilter(c tfclient.ClientContexts) error {
-
-	allItems := []*tfe.Workspace{}
-
+// Returns the workspace list options of the filter flags
+func getWorkspaceFilterOptions() tfe.WorkspaceListOptions {
 	// converts the type of slice from []string to []tfe.WSIncludeOpt Not sure if there is a way to not need this?
 	workspaceIncludes := make([]tfe.WSIncludeOpt, len(includes))
 	for i, v := range includes {
 		workspaceIncludes[i] = tfe.WSIncludeOpt(v)
 	}
 
-	workspaceFilterOpts := tfe.WorkspaceListOptions{
+	return tfe.WorkspaceListOptions{
 		ListOptions:  tfe.ListOptions{PageNumber: 1, PageSize: 100},
 		Search:       searchString,
 		Tags:         tagsString,
@@ -59,31 +65,21 @@ func workspaceFilter(c tfclient.ClientContexts) error {
 		WildcardName: wildcardName,
 		Include:      workspaceIncludes,
 	}
+}
 
-	for {
-		var items *tfe.WorkspaceList
-		var err error
-
-		if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
-			items, err = c.SourceClient.Workspaces.List(c.SourceContext, c.SourceOrganizationName, &workspaceFilterOpts)
-		}
-
-		if ListCmd.Flags().Lookup("side").Value.String() == "destination" {
-			items, err = c.DestinationClient.Workspaces.List(c.DestinationContext, c.DestinationOrganizationName, &workspaceFilterOpts)
-		}
-		if err != nil {
-			return nil
-		}
-
-		allItems = append(allItems, items.Items...)
+// Lists the workspaces matching the filter flags with the schema of `tfm list workspaces`
+func workspaceFilter(c tfclient.ClientContexts) error {
+	return listWorkspaces(c, getWorkspaceFilterOptions())
+}
 
-		if items.CurrentPage >= items.TotalPages {
-			break
-		}
-		workspaceFilterOpts.PageNumber = items.NextPage
+// Compares the filtered workspaces of the source and destination sides with `workspaces-map`
+func workspaceFilterDiff(c tfclient.ClientContexts) error {
+	maps, err := getDiffMaps("workspaces-map", "")
+	if err != nil {
+		return err
 	}
 
-	fmt.Print(len(allItems))
-
-	return nil
+	return listDiff(c, "workspaces", func(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+		return workspaceDiffItems(c, side, getWorkspaceFilterOptions())
+	}, maps)
 }
@@ -5,9 +5,9 @@ package list
 
 import (
 	"fmt"
+	"strconv"
 
-	"encoding/json"
-
+	"github.com/hashicorp-services/tfm/cmd/helper"
 	"github.com/hashicorp-services/tfm/tfclient"
 	"github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
@@ -21,8 +21,18 @@ var (
 		Aliases: []string{"ws"},
 		Short:   "Workspaces command",
 		Long:    "List Workspaces in an org",
-		Run: func(cmd *cobra.Command, args []string) {
-			listWorkspaces(tfclient.GetClientContexts(), jsonOut)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkFormat(); err != nil {
+				return err
+			}
+			compare, err := isDiff()
+			if err != nil {
+				return err
+			}
+			if compare {
+				return listWorkspacesDiff(tfclient.GetClientContexts())
+			}
+			return listWorkspaces(tfclient.GetClientContexts(), tfe.WorkspaceListOptions{})
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -37,211 +47,147 @@ func init() {
 
 }
 
-func listWorkspaces(c tfclient.ClientContexts, jsonOut bool) error {
+// The schema of `tfm list workspaces`
+var workspaceColumns = []column{
+	{"name", "Name"},
+	{"id", "ID"},
+	{"description", "Description"},
+	{"executionMode", "ExecutionMode"},
+	{"repo", "VCS Repo"},
+	{"projectId", "Project ID"},
+	{"projectName", "Project Name"},
+	{"locked", "Locked"},
+	{"terraformVersion", "TF Version"},
+}
 
-	srcWorkspaces := []*tfe.Workspace{}
-	workspaceJSON := make(map[string]interface{}) // Parent JSON object "workspace-names"
-	workspaceData := []map[string]string{}        // workspace names slice to go inside parent object "workspace-names"
+func listWorkspaces(c tfclient.ClientContexts, opts tfe.WorkspaceListOptions) error {
+	listSide := getListSide()
+	client, ctx, org := getSideClient(c, listSide)
 
-	opts := tfe.WorkspaceListOptions{
-		ListOptions: tfe.ListOptions{
-			PageNumber: 1,
-			PageSize:   100},
+	l, err := newListing("workspaces", workspaceColumns)
+	if err != nil {
+		return err
 	}
 
-	if (ListCmd.Flags().Lookup("side").Value.String() == "source") || (!ListCmd.Flags().Lookup("side").Changed) {
-
-		if jsonOut == false {
-			o.AddMessageUserProvided("Getting list of workspaces from: ", c.SourceHostname)
-		}
-
-		for {
-			items, err := c.SourceClient.Workspaces.List(c.SourceContext, c.SourceOrganizationName, &opts)
-			if err != nil {
-				fmt.Println("Error With retrieving Workspaces from ", c.SourceHostname, " : Error ", err)
-				return err
-			}
-
-			srcWorkspaces = append(srcWorkspaces, items.Items...)
-
-			if jsonOut == false {
-				o.AddFormattedMessageCalculated("Found %d Workspaces", len(srcWorkspaces))
-			}
+	o.AddMessageUserProvided("Getting list of workspaces from: ", getSideHostname(c, listSide))
 
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+	projectNames, err := getProjectNames(client, ctx, org)
+	if err != nil {
+		fmt.Println("Error With retrieving Projects from ", getSideHostname(c, listSide), " : Error ", err)
+		return err
+	}
 
-		}
+	workspaces := []*tfe.Workspace{}
 
-		if jsonOut == false {
-			o.AddTableHeaders("Name", "Description", "ExecutionMode", "VCS Repo", "Project ID", "Project Name", "Locked", "TF Version")
+	opts.ListOptions = tfe.ListOptions{PageNumber: 1, PageSize: 100}
+	for {
+		items, err := client.Workspaces.List(ctx, org, &opts)
+		if err != nil {
+			fmt.Println("Error With retrieving Workspaces from ", getSideHostname(c, listSide), " : Error ", err)
+			return err
 		}
-		for _, i := range srcWorkspaces {
-			ws_repo := ""
-			projectID := ""
-			projectName := ""
-
-			if i.VCSRepo != nil {
-				ws_repo = i.VCSRepo.DisplayIdentifier
-			}
 
-			if i.Project != nil {
-				projectID = i.Project.ID
-				prjN, err := getProjectName(c.SourceClient, c.SourceContext, projectID)
-				if err != nil {
-					fmt.Println("Error With retrieving Project Name from ", projectID, " : Error ", err)
-					return err
-				}
+		workspaces = append(workspaces, items.Items...)
 
-				projectName = prjN
-			}
+		o.AddFormattedMessageCalculated("Found %d Workspaces", len(workspaces))
 
-			if i.Project == nil {
-				workspaceInfo := map[string]string{
-					"name":             i.Name,
-					"id":               i.ID,
-					"repo":             ws_repo,
-					"terraformVersion": i.TerraformVersion,
-					"executionMode":    i.ExecutionMode,
-				}
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
 
-				if jsonOut {
-					workspaceData = append(workspaceData, workspaceInfo) // Store workspace name in the slice
-				}
+	for _, i := range workspaces {
+		ws_repo := ""
+		projectID := ""
+		projectName := ""
 
-				if jsonOut == false {
-					o.AddTableRows(i.Name, i.Description, i.ExecutionMode, ws_repo, "none", "none", i.Locked, i.TerraformVersion)
-				}
-			} else {
-				workspaceInfo := map[string]string{
-					"name":             i.Name,
-					"id":               i.ID,
-					"repo":             ws_repo,
-					"projectName":      projectName,
-					"projectId":        i.Project.ID,
-					"terraformVersion": i.TerraformVersion,
-					"executionMode":    i.ExecutionMode,
-				}
-				if jsonOut {
-					workspaceData = append(workspaceData, workspaceInfo) // Store workspace name in the slice
-				}
-				if jsonOut == false {
-					o.AddTableRows(i.Name, i.Description, i.ExecutionMode, ws_repo, projectID, projectName, i.Locked, i.TerraformVersion)
-				}
-			}
+		if i.VCSRepo != nil {
+			ws_repo = i.VCSRepo.DisplayIdentifier
 		}
-		if jsonOut {
-			workspaceJSON["workspaces"] = workspaceData // Assign workspace names to the "workspaces" key
 
-			jsonData, err := json.Marshal(workspaceJSON)
-			if err != nil {
-				fmt.Println("Error marshaling workspaces to JSON:", err)
-				return err
-			}
-
-			fmt.Println(string(jsonData))
+		if i.Project != nil {
+			projectID = i.Project.ID
+			projectName = projectNames[projectID]
 		}
 
+		l.add(i.Name, i.ID, i.Description, i.ExecutionMode, ws_repo, projectID, projectName, i.Locked, i.TerraformVersion)
 	}
 
-	if ListCmd.Flags().Lookup("side").Value.String() == "destination" {
-		if jsonOut == false {
-			o.AddMessageUserProvided("Getting list of workspaces from: ", c.DestinationHostname)
-		}
-
-		for {
-			items, err := c.DestinationClient.Workspaces.List(c.DestinationContext, c.DestinationOrganizationName, &opts)
-			if err != nil {
-				fmt.Println("Error With retrieving Workspaces from ", c.DestinationHostname, " : Error ", err)
-				return err
-			}
+	return l.write()
+}
 
-			srcWorkspaces = append(srcWorkspaces, items.Items...)
+// Lists the workspaces of a side to compare them. Source project names are mapped with `projects-map`.
+func workspaceDiffItems(c tfclient.ClientContexts, side string, opts tfe.WorkspaceListOptions) ([]diffItem, error) {
+	client, ctx, org := getSideClient(c, side)
 
-			if jsonOut == false {
-				o.AddFormattedMessageCalculated("Found %d Workspaces", len(srcWorkspaces))
-			}
+	projectNames, err := getProjectNames(client, ctx, org)
+	if err != nil {
+		return nil, err
+	}
 
-			if items.CurrentPage >= items.TotalPages {
-				break
-			}
-			opts.PageNumber = items.NextPage
+	projectsMap, err := helper.ViperStringSliceMap("projects-map")
+	if err != nil {
+		return nil, fmt.Errorf("invalid input for projects-map")
+	}
 
-		}
+	items := []diffItem{}
 
-		if jsonOut == false {
-			o.AddTableHeaders("Name", "Description", "ExecutionMode", "VCS Repo", "Project ID", "Project Name", "Locked", "TF Version")
+	opts.ListOptions = tfe.ListOptions{PageNumber: 1, PageSize: 100}
+	for {
+		workspaces, err := client.Workspaces.List(ctx, org, &opts)
+		if err != nil {
+			return nil, err
 		}
 
-		for _, i := range srcWorkspaces {
-			ws_repo := ""
-			projectID := ""
-			projectName := ""
-
-			if i.VCSRepo != nil {
-				ws_repo = i.VCSRepo.DisplayIdentifier
+		for _, ws := range workspaces.Items {
+			repo, branch := "", ""
+			if ws.VCSRepo != nil {
+				repo = ws.VCSRepo.Identifier
+				branch = ws.VCSRepo.Branch
 			}
 
-			if i.Project != nil {
-				projectID = i.Project.ID
-				prjN, err := getProjectName(c.DestinationClient, c.DestinationContext, projectID)
-				if err != nil {
-					fmt.Println("Error With retrieving Project Name from ", projectID, " : Error ", err)
-					return err
+			project := ""
+			if ws.Project != nil {
+				project = projectNames[ws.Project.ID]
+				if mapped, ok := projectsMap[project]; ok && side == "source" {
+					project = mapped
 				}
-
-				projectName = prjN
 			}
 
-			if i.Project == nil {
-				workspaceInfo := map[string]string{
-					"name":             i.Name,
-					"id":               i.ID,
-					"repo":             ws_repo,
-					"terraformVersion": i.TerraformVersion,
-					"executionMode":    i.ExecutionMode,
-				}
-
-				if jsonOut {
-					workspaceData = append(workspaceData, workspaceInfo) // Store workspace name in the slice
-				}
-
-				if jsonOut == false {
-					o.AddTableRows(i.Name, i.Description, i.ExecutionMode, ws_repo, "none", "none", i.Locked, i.TerraformVersion)
-				}
-			} else {
-				workspaceInfo := map[string]string{
-					"name":             i.Name,
-					"id":               i.ID,
-					"repo":             ws_repo,
-					"projectName":      projectName,
-					"projectId":        i.Project.ID,
-					"terraformVersion": i.TerraformVersion,
-					"executionMode":    i.ExecutionMode,
-				}
-				if jsonOut {
-					workspaceData = append(workspaceData, workspaceInfo) // Store workspace name in the slice
-				}
-				if jsonOut == false {
-					o.AddTableRows(i.Name, i.Description, i.ExecutionMode, ws_repo, projectID, projectName, i.Locked, i.TerraformVersion)
-				}
-			}
+			items = append(items, diffItem{
+				Name: ws.Name,
+				ID:   ws.ID,
+				Attributes: map[string]string{
+					"description":       ws.Description,
+					"execution-mode":    ws.ExecutionMode,
+					"terraform-version": ws.TerraformVersion,
+					"vcs-repo":          repo,
+					"vcs-branch":        branch,
+					"working-directory": ws.WorkingDirectory,
+					"auto-apply":        strconv.FormatBool(ws.AutoApply),
+					"project":           project,
+				},
+			})
 		}
-		if jsonOut {
 
-			workspaceJSON["workspaces"] = workspaceData // Assign workspace names to the "workspaces" key
+		if workspaces.CurrentPage >= workspaces.TotalPages {
+			break
+		}
+		opts.PageNumber = workspaces.NextPage
+	}
 
-			jsonData, err := json.Marshal(workspaceJSON)
-			if err != nil {
-				fmt.Println("Error marshaling workspaces to JSON:", err)
-				return err
-			}
+	return items, nil
+}
 
-			fmt.Println(string(jsonData))
-		}
+// Compares the workspaces of the source and destination sides with `workspaces-map`
+func listWorkspacesDiff(c tfclient.ClientContexts) error {
+	maps, err := getDiffMaps("workspaces-map", "")
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return listDiff(c, "workspaces", func(c tfclient.ClientContexts, side string) ([]diffItem, error) {
+		return workspaceDiffItems(c, side, tfe.WorkspaceListOptions{})
+	}, maps)
 }
@@ -4,8 +4,10 @@
 package lock
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/hashicorp-services/tfm/cmd/helper"
 	"github.com/hashicorp-services/tfm/output"
@@ -17,7 +19,9 @@ import (
 )
 
 var (
-	o output.Output
+	o           output.Output
+	onActiveRun string
+	runTimeout  time.Duration
 
 	// `tfm list workspaces` command
 	workspacesLockCmd = &cobra.Command{
@@ -25,8 +29,14 @@ var (
 		Aliases: []string{"ws"},
 		Short:   "Workspaces command",
 		Long:    "List Workspaces in an org",
-		Run: func(cmd *cobra.Command, args []string) {
-			LockWorkspaces(tfclient.GetClientContexts())
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if onActiveRun == "" {
+				return nil
+			}
+			return helper.ValidateOnActiveRun(onActiveRun)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return LockWorkspaces(tfclient.GetClientContexts())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			o.Close()
@@ -35,11 +45,15 @@ var (
 )
 
 func init() {
+	workspacesLockCmd.Flags().StringVarP(&onActiveRun, "on-active-run", "", "", "Check the active runs of each workspace before it is locked: wait, skip or fail")
+	workspacesLockCmd.Flags().DurationVarP(&runTimeout, "run-timeout", "", 30*time.Minute, "How long to wait for the active runs of a workspace with --on-active-run=wait before it is skipped")
+
 	// Add commands
 	LockCmd.AddCommand(workspacesLockCmd)
 }
 
 func LockWorkspaces(c tfclient.ClientContexts) error {
+	var skipped []interface{}
 
 	if (LockCmd.Flags().Lookup("side").Value.String() == "source") || (!LockCmd.Flags().Lookup("side").Changed) {
 
@@ -59,16 +73,13 @@ func LockWorkspaces(c tfclient.ClientContexts) error {
 			}
 
 			if !wsProperties.Locked {
-				fmt.Println("Locking Workspace:", ws.Name)
-				message := "tfm migration lock"
-				lockStats, lockErr := c.SourceClient.Workspaces.Lock(c.SourceContext, ws.ID, tfe.WorkspaceLockOptions{
-					Reason: &message,
-				})
-				if lockErr != nil {
-					return lockErr
+				locked, err := lockWorkspace(c.SourceContext, c.SourceClient, ws)
+				if err != nil {
+					return err
+				}
+				if !locked {
+					skipped = append(skipped, ws.Name)
 				}
-
-				_ = lockStats
 			} else {
 				fmt.Println("Workspace is already locked:", ws.Name)
 			}
@@ -93,16 +104,13 @@ func LockWorkspaces(c tfclient.ClientContexts) error {
 			}
 
 			if !wsProperties.Locked {
-				fmt.Println("Locking Workspace:", ws.Name)
-				message := "tfm migration lock"
-				lockStats, lockErr := c.DestinationClient.Workspaces.Lock(c.DestinationContext, ws.ID, tfe.WorkspaceLockOptions{
-					Reason: &message,
-				})
-				if lockErr != nil {
-					return lockErr
+				locked, err := lockWorkspace(c.DestinationContext, c.DestinationClient, ws)
+				if err != nil {
+					return err
+				}
+				if !locked {
+					skipped = append(skipped, ws.Name)
 				}
-
-				_ = lockStats
 			} else {
 				fmt.Println("Workspace is already locked:", ws.Name)
 			}
@@ -110,9 +118,56 @@ func LockWorkspaces(c tfclient.ClientContexts) error {
 
 	}
 
+	if len(skipped) > 0 {
+		o.AddDeferredListMessageRead("Workspaces not locked because of active runs, lock them again once the runs have finished", skipped)
+	}
+
 	return nil
 }
 
+// Locks a workspace. With --on-active-run=skip or fail the active runs of the workspace are
+// checked before it is locked. With --on-active-run=wait the workspace is locked first, so
+// no new runs start, and then its active runs are waited for. A workspace whose runs do not
+// finish is unlocked again. Returns false if the workspace was skipped.
+func lockWorkspace(ctx context.Context, client *tfe.Client, ws *tfe.Workspace) (bool, error) {
+	if onActiveRun == helper.OnActiveRunSkip || onActiveRun == helper.OnActiveRunFail {
+		ready, err := helper.CheckActiveRuns(ctx, client, ws, onActiveRun, runTimeout, false)
+		if err != nil || !ready {
+			return false, err
+		}
+	}
+
+	fmt.Println("Locking Workspace:", ws.Name)
+	message := "tfm migration lock"
+	lockStats, lockErr := client.Workspaces.Lock(ctx, ws.ID, tfe.WorkspaceLockOptions{
+		Reason: &message,
+	})
+	if lockErr != nil {
+		return false, lockErr
+	}
+
+	_ = lockStats
+
+	if onActiveRun == helper.OnActiveRunWait {
+		err := helper.WaitForRuns(ctx, client, ws.ID, runTimeout, false)
+		if err != nil {
+			fmt.Println("Unlocking Workspace:", ws.Name)
+			if _, unlockErr := client.Workspaces.Unlock(ctx, ws.ID); unlockErr != nil {
+				return false, errors.Wrapf(unlockErr, "workspace %v is left locked, failed to unlock it after: %v", ws.Name, err)
+			}
+		}
+		if errors.Is(err, helper.ErrRunsNotFinished) {
+			fmt.Printf("Skipping workspace %v: %v\n", ws.Name, err)
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
 func getSrcWorkspacesFilter(c tfclient.ClientContexts, wsList []string) ([]*tfe.Workspace, error) {
 	// o.AddMessageUserProvided("Getting list of Workspaces from:", c.SourceHostname)
 
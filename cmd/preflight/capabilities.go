@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package preflight
+
+import (
+	"strconv"
+
+	"github.com/hashicorp-services/tfm/output"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// `tfm preflight` command
+var (
+	o            output.Output
+	PreflightCmd = &cobra.Command{
+		Use:   "preflight",
+		Short: "Show the preflight checks",
+		Long:  "Probes the token permissions, release, plan, entitlements and workspace limit of the source and destination organizations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showCapabilities()
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+// A row of the `tfm preflight` table and how to read its value from the capabilities of a side
+type capabilityRow struct {
+	name  string
+	value func(c *Capabilities) string
+}
+
+// Formats a permission or entitlement that may not be reported
+func yesNo(known bool, value bool) string {
+	if !known {
+		return "unknown"
+	}
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// Returns the row of a permission
+func permissionRow(name string, permission func(p *tfe.OrganizationPermissions) bool) capabilityRow {
+	return capabilityRow{name, func(c *Capabilities) string {
+		return yesNo(c.Permissions != nil, c.Permissions != nil && permission(c.Permissions))
+	}}
+}
+
+// Returns the row of an entitlement
+func entitlementRow(name string, entitlement func(e *tfe.Entitlements) bool) capabilityRow {
+	return capabilityRow{name, func(c *Capabilities) string {
+		return yesNo(c.Entitlements != nil, c.Entitlements != nil && entitlement(c.Entitlements))
+	}}
+}
+
+var capabilityRows = []capabilityRow{
+	{"Organization", func(c *Capabilities) string { return c.String() }},
+	{"Release", func(c *Capabilities) string { return c.Release() }},
+	{"API Version", func(c *Capabilities) string { return c.APIVersion }},
+	{"Plan", func(c *Capabilities) string {
+		switch {
+		case !c.Cloud:
+			return "n/a"
+		case c.Plan == "":
+			return "unknown"
+		}
+		return c.Plan
+	}},
+	permissionRow("Owner Token", func(p *tfe.OrganizationPermissions) bool { return p.CanUpdate }),
+	permissionRow("Manage Workspaces", func(p *tfe.OrganizationPermissions) bool { return p.CanCreateWorkspace }),
+	permissionRow("Manage Teams", func(p *tfe.OrganizationPermissions) bool { return p.CanCreateTeam }),
+	permissionRow("Manage VCS Settings", func(p *tfe.OrganizationPermissions) bool { return p.CanUpdateOAuth }),
+	{"Projects API", func(c *Capabilities) string {
+		if c.ProjectsErr != nil {
+			return "no permission"
+		}
+		return yesNo(true, c.Projects)
+	}},
+	entitlementRow("Agents", func(e *tfe.Entitlements) bool { return e.Agents }),
+	entitlementRow("Remote Operations", func(e *tfe.Entitlements) bool { return e.Operations }),
+	entitlementRow("State Storage", func(e *tfe.Entitlements) bool { return e.StateStorage }),
+	entitlementRow("Teams", func(e *tfe.Entitlements) bool { return e.Teams }),
+	entitlementRow("VCS Integrations", func(e *tfe.Entitlements) bool { return e.VCSIntegrations }),
+	entitlementRow("Sentinel", func(e *tfe.Entitlements) bool { return e.Sentinel }),
+	{"Workspaces", func(c *Capabilities) string { return strconv.Itoa(c.Workspaces) }},
+	{"Workspace Limit", func(c *Capabilities) string {
+		if c.WorkspaceLimit == nil {
+			return "none or unknown"
+		}
+		return strconv.Itoa(*c.WorkspaceLimit)
+	}},
+}
+
+// Main function for `tfm preflight`
+func showCapabilities() error {
+	sides := []*Capabilities{}
+	for _, side := range bothSides {
+		c, err := probe(side)
+		if err != nil {
+			o.AddErrorUserProvided2("Preflight checks failed:", err.Error())
+			continue
+		}
+		sides = append(sides, c)
+	}
+
+	if len(sides) == 0 {
+		return errors.New("neither the source nor the destination could be probed")
+	}
+
+	headers := []interface{}{"Check"}
+	for _, c := range sides {
+		headers = append(headers, c.Side)
+	}
+	o.AddTableHeaders(headers...)
+
+	for _, row := range capabilityRows {
+		cells := []interface{}{row.name}
+		for _, c := range sides {
+			cells = append(cells, row.value(c))
+		}
+		o.AddTableRows(cells...)
+	}
+
+	return nil
+}
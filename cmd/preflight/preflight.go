@@ -0,0 +1,342 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/logrusorgru/aurora"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// All functions related to the preflight checks that run before a command starts
+
+// The sides of a migration
+const (
+	sideSource      = "source"
+	sideDestination = "destination"
+)
+
+// The capabilities of an organization detected by the preflight probes
+type Capabilities struct {
+	Side         string
+	Hostname     string
+	Organization string
+	// True for HCP Terraform, false for Terraform Enterprise
+	Cloud bool
+	// The TFE release, empty for HCP Terraform and TFE releases before v202208-3
+	TFEVersion string
+	APIVersion string
+	// The permissions of the token in the organization, nil if they are not reported
+	Permissions *tfe.OrganizationPermissions
+	// The entitlements of the organization, nil if they could not be read
+	Entitlements *tfe.Entitlements
+	// True if the projects API is available
+	Projects bool
+	// The error of listing projects when the projects API is available but the token can not list them
+	ProjectsErr error
+	Workspaces  int
+	// The workspace limit of the organization, nil if it is unlimited or could not be read.
+	// Reading the limit requires a TFE site admin token.
+	WorkspaceLimit *int
+	// The HCP Terraform plan of the organization, empty for TFE or if it could not be read
+	Plan string
+	// True if the organization is on the HCP Terraform free tier
+	FreeTier bool
+
+	client *tfe.Client
+	ctx    context.Context
+}
+
+// The capabilities of the sides probed for the running command, nil if the command does not
+// use the side or the preflight checks were skipped
+var (
+	Source      *Capabilities
+	Destination *Capabilities
+)
+
+// The HCP Terraform subscription of an organization and its plan. go-tfe does not expose
+// subscriptions, so they are read with these minimal JSON:API models.
+type subscription struct {
+	ID               string      `jsonapi:"primary,subscriptions"`
+	IsPublicFreeTier bool        `jsonapi:"attr,is-public-free-tier"`
+	FeatureSet       *featureSet `jsonapi:"relation,feature-set"`
+}
+
+type featureSet struct {
+	ID   string `jsonapi:"primary,feature-sets"`
+	Name string `jsonapi:"attr,name"`
+}
+
+// Reads the HCP Terraform subscription of the organization of a side
+func readSubscription(c *Capabilities) (*subscription, error) {
+	req, err := c.client.NewRequest("GET", fmt.Sprintf("organizations/%s/subscription", url.PathEscape(c.Organization)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &subscription{}
+	if err := req.Do(c.ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Returns a description of the TFE release or HCP Terraform
+func (c *Capabilities) Release() string {
+	if c.Cloud {
+		return "HCP Terraform"
+	}
+	if c.TFEVersion == "" {
+		return "Terraform Enterprise (before v202208-3)"
+	}
+	return "Terraform Enterprise " + c.TFEVersion
+}
+
+// Returns the hostname and organization of the side
+func (c *Capabilities) String() string {
+	return c.Hostname + "/" + c.Organization
+}
+
+// Returns the config file keys of the hostname, organization and token of a side
+func sideKeys(side string) (string, string, string) {
+	if side == sideDestination {
+		return "dst_tfc_hostname", "dst_tfc_org", "dst_tfc_token"
+	}
+	return "src_tfe_hostname", "src_tfe_org", "src_tfe_token"
+}
+
+// Probes the token permissions, release, entitlements and limits of the organization of a side
+func probe(side string) (*Capabilities, error) {
+	hostnameKey, orgKey, tokenKey := sideKeys(side)
+	hostname, org, token := viper.GetString(hostnameKey), viper.GetString(orgKey), viper.GetString(tokenKey)
+
+	if hostname == "" || org == "" || token == "" {
+		return nil, fmt.Errorf("the %v is not configured, set %v, %v and %v in the config file or the environment", side, hostnameKey, orgKey, tokenKey)
+	}
+
+	client, err := tfe.NewClient(&tfe.Config{
+		Address:           "https://" + hostname,
+		Token:             token,
+		RetryServerErrors: true,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not connect to the %v %v", side, hostname)
+	}
+
+	c := &Capabilities{
+		Side:         side,
+		Hostname:     hostname,
+		Organization: org,
+		Cloud:        client.IsCloud(),
+		TFEVersion:   client.RemoteTFEVersion(),
+		APIVersion:   client.RemoteAPIVersion(),
+		client:       client,
+		ctx:          context.Background(),
+	}
+
+	organization, err := client.Organizations.Read(c.ctx, org)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the %v token can not read organization %v on %v, check %v and %v", side, org, hostname, tokenKey, orgKey)
+	}
+	c.Permissions = organization.Permissions
+
+	// Entitlements are not available on all TFE releases. Assume entitled if they cannot be read.
+	if entitlements, err := client.Organizations.ReadEntitlements(c.ctx, org); err == nil {
+		c.Entitlements = entitlements
+	}
+
+	// Only releases without projects return not found, any other error is a permissions failure
+	_, err = client.Projects.List(c.ctx, org, &tfe.ProjectListOptions{ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 1}})
+	c.Projects = err != tfe.ErrResourceNotFound
+	if c.Projects && err != nil {
+		c.ProjectsErr = err
+	}
+
+	workspaces, err := client.Workspaces.List(c.ctx, org, &tfe.WorkspaceListOptions{ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 1}})
+	if err != nil {
+		return nil, errors.Wrapf(err, "the %v token can not list the workspaces of organization %v on %v", side, org, hostname)
+	}
+	c.Workspaces = len(workspaces.Items)
+	if workspaces.Pagination != nil {
+		c.Workspaces = workspaces.TotalCount
+	}
+
+	// HCP Terraform plans limit resources under management instead of workspaces
+	if c.Cloud {
+		if s, err := readSubscription(c); err == nil {
+			c.FreeTier = s.IsPublicFreeTier
+			if s.FeatureSet != nil {
+				c.Plan = s.FeatureSet.Name
+			}
+		}
+	} else {
+		if adminOrg, err := client.Admin.Organizations.Read(c.ctx, org); err == nil {
+			c.WorkspaceLimit = adminOrg.WorkspaceLimit
+		}
+	}
+
+	return c, nil
+}
+
+// Returns the sides a command uses. Commands with a `--side` flag use the selected side, or both
+// sides with `--side both`.
+func getSides(cmd *cobra.Command, r requirements) []string {
+	if r.defaultSide == "" {
+		return r.sides
+	}
+
+	side := r.defaultSide
+	if f := cmd.Flags().Lookup("side"); f != nil && f.Value.String() != "" {
+		side = f.Value.String()
+	}
+
+	switch side {
+	case sideSource, sideDestination:
+		return []string{side}
+	}
+	return []string{sideSource, sideDestination}
+}
+
+// Returns true if a boolean flag of the command is set
+func isFlagSet(cmd *cobra.Command, name string) bool {
+	f := cmd.Flags().Lookup(name)
+	return f != nil && f.Value.String() == "true"
+}
+
+// Prints a preflight notice. Notices are printed to stderr to keep json, csv and yaml output
+// on stdout valid.
+func notice(format string, args ...interface{}) {
+	fmt.Fprintln(os.Stderr, aurora.Yellow("Preflight: "+fmt.Sprintf(format, args...)))
+}
+
+// Returns the explanations of the needs a side does not meet. Unmet needs that only warn are
+// printed as notices.
+func unmet(c *Capabilities, needs []need) []string {
+	explanations := []string{}
+	if c == nil {
+		return explanations
+	}
+
+	for _, n := range needs {
+		if n.met(c) {
+			continue
+		}
+		if n.warn {
+			notice("%v %v: %v", c.Side, c, n.explain(c))
+			continue
+		}
+		explanations = append(explanations, fmt.Sprintf("%v %v: %v", c.Side, c, n.explain(c)))
+	}
+	return explanations
+}
+
+// Runs the preflight checks of a command. Returns an error explaining every unmet requirement if
+// the command can not start. Options the sides do not support are disabled with a notice.
+func Run(cmd *cobra.Command) error {
+	if viper.GetBool("skip-preflight") {
+		return nil
+	}
+
+	r, ok := commands[cmd.CommandPath()]
+	if !ok {
+		return nil
+	}
+
+	problems := []string{}
+	for _, side := range getSides(cmd, r) {
+		c, err := probe(side)
+		if err != nil {
+			return errors.Wrap(err, "Preflight checks failed for `"+cmd.CommandPath()+"`")
+		}
+
+		if side == sideSource {
+			Source = c
+			problems = append(problems, unmet(c, r.source)...)
+		} else {
+			Destination = c
+			problems = append(problems, unmet(c, r.destination)...)
+		}
+		problems = append(problems, unmet(c, r.sided)...)
+	}
+
+	// Needs of commands that create workspaces only print a notice when no workspaces are created
+	creates := r.createsWorkspaces != nil && r.createsWorkspaces(cmd)
+	for _, n := range r.creating {
+		if !creates {
+			n = warn(n)
+		}
+		problems = append(problems, unmet(Destination, []need{n})...)
+	}
+
+	for _, opt := range r.options {
+		if !isFlagSet(cmd, opt.flag) {
+			continue
+		}
+
+		explanations := append(unmet(Source, opt.source), unmet(Destination, opt.destination)...)
+		if len(explanations) == 0 {
+			continue
+		}
+
+		if opt.disable {
+			notice("--%v is disabled, %v", opt.flag, explanations[0])
+			cmd.Flags().Set(opt.flag, "false")
+			continue
+		}
+
+		for _, e := range explanations {
+			problems = append(problems, "--"+opt.flag+" on the "+e)
+		}
+	}
+
+	if creates && Destination != nil {
+		if err := checkWorkspaceLimit(r.plannedWorkspaces); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Preflight checks failed, `%v` can not start:\n  - %v\nUse --skip-preflight to run the command anyway",
+			cmd.CommandPath(), strings.Join(problems, "\n  - "))
+	}
+
+	return nil
+}
+
+// Checks that the workspaces a command creates fit in the workspace limit of the destination.
+// Planned returns the number of workspaces the command creates, nil if it is not known upfront.
+func checkWorkspaceLimit(planned func() (int, error)) error {
+	if Destination.WorkspaceLimit == nil {
+		return nil
+	}
+	limit := *Destination.WorkspaceLimit
+
+	if Destination.Workspaces >= limit {
+		return fmt.Errorf("destination %v has reached its workspace limit of %d", Destination, limit)
+	}
+
+	if planned == nil {
+		return nil
+	}
+
+	create, err := planned()
+	if err != nil {
+		return err
+	}
+
+	if Destination.Workspaces+create > limit {
+		return fmt.Errorf("destination %v has %d workspaces, creating %d more would exceed its workspace limit of %d",
+			Destination, Destination.Workspaces, create, limit)
+	}
+
+	return nil
+}
@@ -0,0 +1,337 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package preflight
+
+import (
+	"fmt"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// All functions related to the requirements of the commands checked by the preflight checks
+
+// A permission, entitlement or API a command needs from an organization
+type need struct {
+	met func(c *Capabilities) bool
+	// Explains what is missing when the need is not met
+	explain func(c *Capabilities) string
+	// Print a notice instead of refusing to start when the need is not met. Used for
+	// permissions that can not be read from the organization.
+	warn bool
+}
+
+// An option of a command and what it needs from the sides when its flag is set
+type option struct {
+	flag        string
+	source      []need
+	destination []need
+	// Disable the option with a notice instead of refusing to start the command
+	disable bool
+}
+
+// What a command needs from the source and destination organizations
+type requirements struct {
+	// The sides the command uses
+	sides []string
+	// Commands with a `--side` flag use the selected side, or defaultSide if no side is selected
+	defaultSide string
+	source      []need
+	destination []need
+	// Needs of the side selected with `--side`
+	sided []need
+	// Needs of the destination when the command creates workspaces. Only a notice is printed
+	// for them when it does not.
+	creating []need
+	options  []option
+	// Returns true if the command creates workspaces in the destination, which are checked
+	// against the workspace limit of the destination
+	createsWorkspaces func(cmd *cobra.Command) bool
+	// Optional: Returns the number of workspaces the command creates
+	plannedWorkspaces func() (int, error)
+}
+
+var bothSides = []string{sideSource, sideDestination}
+
+// Permissions of the token in the organization. Permissions that are not reported are assumed.
+var (
+	manageWorkspaces = need{
+		met: func(c *Capabilities) bool { return c.Permissions == nil || c.Permissions.CanCreateWorkspace },
+		explain: func(c *Capabilities) string {
+			return "the token can not manage workspaces, use a token of the owners team, of a team with the manage workspaces permission, or an organization token"
+		},
+	}
+
+	manageTeams = need{
+		met: func(c *Capabilities) bool { return c.Permissions == nil || c.Permissions.CanCreateTeam },
+		explain: func(c *Capabilities) string {
+			return "the token can not manage teams, use a token of the owners team or an organization token"
+		},
+	}
+
+	manageVCS = need{
+		met: func(c *Capabilities) bool { return c.Permissions == nil || c.Permissions.CanUpdateOAuth },
+		explain: func(c *Capabilities) string {
+			return "the token can not manage VCS settings, use a token of the owners team or of a team with the manage VCS settings permission"
+		},
+	}
+
+	owner = need{
+		met: func(c *Capabilities) bool { return c.Permissions == nil || c.Permissions.CanUpdate },
+		explain: func(c *Capabilities) string {
+			return "the token is not an owner token, use a token of the owners team"
+		},
+	}
+
+	projects = need{
+		met: func(c *Capabilities) bool { return c.Projects && c.ProjectsErr == nil },
+		explain: func(c *Capabilities) string {
+			if c.ProjectsErr != nil {
+				return "the token can not list projects: " + c.ProjectsErr.Error() + ", use a token of the owners team, of a team with the manage projects permission, or an organization token"
+			}
+			return "the projects API is not available in " + c.Release() + ", projects need Terraform Enterprise v202302-1 or later"
+		},
+	}
+)
+
+// The resources under management of the HCP Terraform free tier
+const freeTierResources = 500
+
+// States copied to an organization on the HCP Terraform free tier count against its resource
+// limit. The resources of the source states can not be counted upfront, so only a notice is printed.
+var resourceLimit = need{
+	met: func(c *Capabilities) bool { return !c.FreeTier },
+	explain: func(c *Capabilities) string {
+		plan := c.Plan
+		if plan == "" {
+			plan = "free"
+		}
+		return fmt.Sprintf("the organization is on the %v plan of HCP Terraform, which is limited to %d resources under management. Run `tfm report rum` to count the resources of the source states",
+			plan, freeTierResources)
+	},
+	warn: true,
+}
+
+// Returns a need that only prints a notice when it is not met
+func warn(n need) need {
+	n.warn = true
+	return n
+}
+
+// Returns the owner need with a notice about what the command manages. Teams other than the
+// owners team can be granted these permissions, which are not reported by the API.
+func ownerFor(what string) need {
+	return need{
+		met: owner.met,
+		explain: func(c *Capabilities) string {
+			return "the token is not an owner token, the command fails unless its team can " + what
+		},
+		warn: true,
+	}
+}
+
+// Returns the need of an entitlement. Organizations are assumed entitled if the entitlements
+// could not be read.
+func entitled(name string, entitlement func(e *tfe.Entitlements) bool) need {
+	return need{
+		met: func(c *Capabilities) bool { return c.Entitlements == nil || entitlement(c.Entitlements) },
+		explain: func(c *Capabilities) string {
+			return "the organization is not entitled to " + name + " in " + c.Release()
+		},
+	}
+}
+
+var (
+	agents          = entitled("agents", func(e *tfe.Entitlements) bool { return e.Agents })
+	operations      = entitled("remote operations", func(e *tfe.Entitlements) bool { return e.Operations })
+	stateStorage    = entitled("state storage", func(e *tfe.Entitlements) bool { return e.StateStorage })
+	teams           = entitled("teams", func(e *tfe.Entitlements) bool { return e.Teams })
+	vcsIntegrations = entitled("VCS integrations", func(e *tfe.Entitlements) bool { return e.VCSIntegrations })
+)
+
+// A `dst_tfc_project_id` in the config file needs the projects API
+var projectID = need{
+	met: func(c *Capabilities) bool { return viper.GetString("dst_tfc_project_id") == "" || c.Projects },
+	explain: func(c *Capabilities) string {
+		return "dst_tfc_project_id is set, but " + projects.explain(c)
+	},
+}
+
+// The flags of `tfm copy workspaces` that copy workspace settings instead of creating workspaces
+var copyWorkspacesModes = []string{"state", "vars", "teamaccess", "agents", "vcs", "ssh", "remote-state-sharing", "run-triggers", "config-versions"}
+
+// Returns true if `tfm copy workspaces` creates workspaces
+func createsCopiedWorkspaces(cmd *cobra.Command) bool {
+	for _, flag := range copyWorkspacesModes {
+		if isFlagSet(cmd, flag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true for commands that always create workspaces
+func always(cmd *cobra.Command) bool {
+	return true
+}
+
+// Lists the workspace names of a side
+func listWorkspaceNames(c *Capabilities) (map[string]bool, error) {
+	names := map[string]bool{}
+
+	opts := tfe.WorkspaceListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		items, err := c.client.Workspaces.List(c.ctx, c.Organization, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ws := range items.Items {
+			names[ws.Name] = true
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return names, nil
+}
+
+// Returns the number of workspaces `tfm copy workspaces` creates: the workspaces of the
+// `workspaces` list, the values of `workspaces-map` or all source workspaces that do not
+// exist in the destination
+func plannedCopiedWorkspaces() (int, error) {
+	planned, err := helper.GetWorkspaceNamesCfg("destination")
+	if err != nil {
+		return 0, err
+	}
+
+	if len(planned) == 0 {
+		srcNames, err := listWorkspaceNames(Source)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to list the source workspaces")
+		}
+		for name := range srcNames {
+			planned = append(planned, name)
+		}
+	}
+
+	destNames, err := listWorkspaceNames(Destination)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list the destination workspaces")
+	}
+
+	create := 0
+	for _, name := range planned {
+		if !destNames[name] {
+			create++
+		}
+	}
+	return create, nil
+}
+
+// The requirements of the commands by command path. Commands that are not listed, such as
+// `tfm generate config`, do not connect to an organization and have no preflight checks.
+var commands = map[string]requirements{
+	"tfm assess": {sides: bothSides},
+	"tfm verify": {
+		sides:   bothSides,
+		options: []option{{flag: "plan", destination: []need{operations}}},
+	},
+
+	"tfm copy teams": {
+		sides:       bothSides,
+		source:      []need{teams},
+		destination: []need{manageTeams, teams},
+	},
+	"tfm copy organization-settings": {
+		sides:       bothSides,
+		destination: []need{owner},
+	},
+	"tfm copy projects": {
+		sides:       bothSides,
+		source:      []need{projects},
+		destination: []need{projects, ownerFor("manage projects")},
+		options:     []option{{flag: "teamaccess", source: []need{teams}, destination: []need{teams}, disable: true}},
+	},
+	"tfm copy varsets": {
+		sides:       bothSides,
+		destination: []need{ownerFor("manage variable sets")},
+	},
+	"tfm copy agent-pools": {
+		sides:       bothSides,
+		source:      []need{agents},
+		destination: []need{agents, ownerFor("manage agent pools")},
+	},
+	"tfm copy workspaces": {
+		sides:       bothSides,
+		destination: []need{projectID},
+		creating:    []need{manageWorkspaces},
+		options: []option{
+			{flag: "state", destination: []need{stateStorage, resourceLimit}},
+			{flag: "teamaccess", source: []need{teams}, destination: []need{teams}},
+			{flag: "agents", destination: []need{agents}},
+			{flag: "vcs", destination: []need{vcsIntegrations}},
+		},
+		createsWorkspaces: createsCopiedWorkspaces,
+		plannedWorkspaces: plannedCopiedWorkspaces,
+	},
+	"tfm cutover": {
+		sides:       bothSides,
+		source:      []need{manageWorkspaces},
+		destination: []need{manageWorkspaces, stateStorage, resourceLimit},
+	},
+
+	"tfm list organization":     {defaultSide: sideSource},
+	"tfm list projects":         {defaultSide: sideSource, sided: []need{projects}},
+	"tfm list ssh":              {defaultSide: sideSource, sided: []need{manageVCS}},
+	"tfm list states":           {defaultSide: sideSource},
+	"tfm list teams":            {defaultSide: sideSource, sided: []need{teams}},
+	"tfm list vcs":              {defaultSide: sideSource, sided: []need{manageVCS}},
+	"tfm list vcs-gha":          {defaultSide: sideSource, sided: []need{manageVCS}},
+	"tfm list workspace-filter": {defaultSide: sideSource},
+	"tfm list workspaces":       {defaultSide: sideSource},
+
+	"tfm lock workspaces":   {defaultSide: sideSource, sided: []need{warn(manageWorkspaces)}},
+	"tfm unlock workspaces": {defaultSide: sideSource, sided: []need{warn(manageWorkspaces)}},
+
+	"tfm delete workspace": {defaultSide: sideSource, sided: []need{manageWorkspaces}},
+	"tfm delete workspaces-vcs": {
+		sides:  []string{sideSource},
+		source: []need{manageWorkspaces},
+	},
+
+	"tfm report rum": {sides: []string{sideSource}},
+
+	"tfm backup states": {defaultSide: sideSource},
+	// Restores default to the side in the backup manifest, both sides are checked
+	"tfm restore states": {defaultSide: "both", sided: []need{stateStorage, resourceLimit}},
+
+	"tfm core create-workspaces": {
+		sides:             []string{sideDestination},
+		creating:          []need{manageWorkspaces},
+		createsWorkspaces: always,
+	},
+	"tfm core upload-state": {
+		sides:       []string{sideDestination},
+		destination: []need{stateStorage, resourceLimit},
+	},
+	"tfm core link-vcs": {
+		sides:       []string{sideDestination},
+		destination: []need{manageWorkspaces, vcsIntegrations},
+	},
+	"tfm core migrate": {
+		sides:             []string{sideDestination},
+		destination:       []need{stateStorage, vcsIntegrations, resourceLimit},
+		creating:          []need{manageWorkspaces},
+		createsWorkspaces: always,
+	},
+}
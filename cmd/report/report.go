@@ -0,0 +1,34 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"github.com/hashicorp-services/tfm/output"
+	"github.com/spf13/cobra"
+)
+
+// The `--format` report formats
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+// `tfm report` commands
+var (
+	o          output.Output
+	format     string
+	outputFile string
+
+	ReportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Report",
+		Long:  "Reports on objects in the source org",
+	}
+)
+
+func init() {
+	ReportCmd.PersistentFlags().StringVar(&format, "format", formatTable, "Report format: table, csv or json")
+	ReportCmd.PersistentFlags().StringVar(&outputFile, "output", "", "Write the report to a file instead of stdout")
+}
@@ -0,0 +1,382 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/cmd/preflight"
+	"github.com/hashicorp-services/tfm/tfclient"
+	"github.com/hashicorp-services/tfm/tfstate"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// The `--by` aggregation levels
+const (
+	byWorkspace    = "workspace"
+	byProject      = "project"
+	byOrganization = "organization"
+)
+
+var (
+	by string
+
+	// `tfm report rum` command
+	rumReportCmd = &cobra.Command{
+		Use:   "rum",
+		Short: "Resources under management",
+		Long: `Counts the resources under management (RUM) of the source workspaces from their current states.
+Managed resource instances are counted, data sources and placeholder resources such as null_resource
+and terraform_data are not, as in HCP Terraform billing.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatTable && format != formatCSV && format != formatJSON {
+				return fmt.Errorf("invalid --format %q, expected table, csv or json", format)
+			}
+			if by != byWorkspace && by != byProject && by != byOrganization {
+				return fmt.Errorf("invalid --by %q, expected workspace, project or organization", by)
+			}
+			o.JsonOutput = format != formatTable && outputFile == ""
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return reportRUM(tfclient.GetClientContexts())
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	rumReportCmd.Flags().StringVar(&by, "by", byWorkspace, "Aggregate the table and csv formats by workspace, project or organization. The json format has all levels")
+
+	// Add commands
+	ReportCmd.AddCommand(rumReportCmd)
+}
+
+// The resources under management of a workspace
+type workspaceRUM struct {
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	ProjectID    string `json:"projectId"`
+	Workspace    string `json:"workspace"`
+	WorkspaceID  string `json:"workspaceId"`
+	tfstate.RUM
+	// The time of the current state, nil if the workspace has no state
+	LastUpdated *time.Time `json:"lastUpdated"`
+}
+
+// The resources under management of the workspaces of a project
+type projectRUM struct {
+	Organization string `json:"organization"`
+	Project      string `json:"project"`
+	ProjectID    string `json:"projectId"`
+	Workspaces   int    `json:"workspaces"`
+	tfstate.RUM
+}
+
+// The resources under management of the workspaces of an organization
+type organizationRUM struct {
+	Organization string `json:"organization"`
+	Projects     int    `json:"projects"`
+	Workspaces   int    `json:"workspaces"`
+	tfstate.RUM
+}
+
+// The resources under management report of the source organization
+type rumReport struct {
+	Hostname      string            `json:"hostname"`
+	GeneratedAt   time.Time         `json:"generatedAt"`
+	Workspaces    []workspaceRUM    `json:"workspaces"`
+	Projects      []projectRUM      `json:"projects"`
+	Organizations []organizationRUM `json:"organizations"`
+}
+
+// Returns the names of the source projects by ID. Releases without projects have none.
+func getProjectNames(c tfclient.ClientContexts) (map[string]string, error) {
+	names := map[string]string{}
+	if preflight.Source != nil && !preflight.Source.Projects {
+		return names, nil
+	}
+
+	opts := tfe.ProjectListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 100},
+	}
+	for {
+		items, err := c.SourceClient.Projects.List(c.SourceContext, c.SourceOrganizationName, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range items.Items {
+			names[p.ID] = p.Name
+		}
+
+		if items.CurrentPage >= items.TotalPages {
+			break
+		}
+		opts.PageNumber = items.NextPage
+	}
+
+	return names, nil
+}
+
+// Downloads the current state of a workspace and counts its resources. Workspaces without a
+// state have no resources.
+func countWorkspaceRUM(c tfclient.ClientContexts, ws *tfe.Workspace) (tfstate.RUM, *time.Time, error) {
+	current, err := c.SourceClient.StateVersions.ReadCurrent(c.SourceContext, ws.ID)
+	if err == tfe.ErrResourceNotFound {
+		return tfstate.RUM{}, nil, nil
+	}
+	if err != nil {
+		return tfstate.RUM{}, nil, errors.Wrap(err, "failed to read the current state")
+	}
+
+	f, err := tfstate.Download(c.SourceContext, c.SourceClient, current.DownloadURL)
+	if err != nil {
+		return tfstate.RUM{}, nil, errors.Wrap(err, "failed to download the current state")
+	}
+	defer f.Remove()
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return tfstate.RUM{}, nil, err
+	}
+	defer file.Close()
+
+	rum, err := tfstate.CountRUM(file)
+	if err != nil {
+		return tfstate.RUM{}, nil, errors.Wrap(err, "failed to count the resources of the current state")
+	}
+
+	return *rum, &current.CreatedAt, nil
+}
+
+// Aggregates the workspaces of the report by project and organization
+func (r *rumReport) aggregate() {
+	projects := map[string]*projectRUM{}
+	org := organizationRUM{}
+
+	for _, ws := range r.Workspaces {
+		org.Organization = ws.Organization
+		org.Workspaces++
+		org.Add(ws.RUM)
+
+		p, ok := projects[ws.ProjectID]
+		if !ok {
+			p = &projectRUM{Organization: ws.Organization, Project: ws.Project, ProjectID: ws.ProjectID}
+			projects[ws.ProjectID] = p
+		}
+		p.Workspaces++
+		p.Add(ws.RUM)
+	}
+
+	for _, p := range projects {
+		r.Projects = append(r.Projects, *p)
+	}
+	sort.Slice(r.Projects, func(i, j int) bool { return r.Projects[i].Project < r.Projects[j].Project })
+
+	if org.Workspaces > 0 {
+		org.Projects = len(r.Projects)
+		r.Organizations = append(r.Organizations, org)
+	}
+}
+
+// Returns the table headers, csv keys and rows of an aggregation level
+func (r *rumReport) rows(level string) ([]interface{}, []string, [][]interface{}) {
+	rows := [][]interface{}{}
+
+	switch level {
+	case byProject:
+		for _, p := range r.Projects {
+			rows = append(rows, []interface{}{p.Organization, p.Project, p.ProjectID, p.Workspaces, p.Managed, p.DataSources, p.Placeholders})
+		}
+		return []interface{}{"Organization", "Project", "Project ID", "Workspaces", "RUM", "Data Sources", "Placeholders"},
+			[]string{"organization", "project", "projectId", "workspaces", "rum", "dataSources", "placeholders"}, rows
+
+	case byOrganization:
+		for _, org := range r.Organizations {
+			rows = append(rows, []interface{}{org.Organization, org.Projects, org.Workspaces, org.Managed, org.DataSources, org.Placeholders})
+		}
+		return []interface{}{"Organization", "Projects", "Workspaces", "RUM", "Data Sources", "Placeholders"},
+			[]string{"organization", "projects", "workspaces", "rum", "dataSources", "placeholders"}, rows
+	}
+
+	for _, ws := range r.Workspaces {
+		var lastUpdated interface{}
+		if ws.LastUpdated != nil {
+			lastUpdated = *ws.LastUpdated
+		}
+		rows = append(rows, []interface{}{ws.Organization, ws.Project, ws.ProjectID, ws.Workspace, ws.WorkspaceID, ws.Managed, ws.DataSources, ws.Placeholders, lastUpdated})
+	}
+	return []interface{}{"Organization", "Project", "Project ID", "Workspace", "Workspace ID", "RUM", "Data Sources", "Placeholders", "Last Updated"},
+		[]string{"organization", "project", "projectId", "workspace", "workspaceId", "rum", "dataSources", "placeholders", "lastUpdated"}, rows
+}
+
+// Returns the cells of a table row. Times are formatted for display.
+func formatCells(row []interface{}) []interface{} {
+	cells := []interface{}{}
+	for _, cell := range row {
+		switch value := cell.(type) {
+		case nil:
+			cells = append(cells, "")
+		case time.Time:
+			cells = append(cells, helper.FormatDateTime(value))
+		default:
+			cells = append(cells, value)
+		}
+	}
+	return cells
+}
+
+// Renders a table for the `--output` file
+func renderTable(headers []interface{}, rows [][]interface{}) string {
+	t := table.NewWriter()
+	t.AppendHeader(headers)
+	for _, row := range rows {
+		t.AppendRow(formatCells(row))
+	}
+	t.SetStyle(table.StyleRounded)
+	return t.Render() + "\n"
+}
+
+// Renders the rows of the `--by` level as CSV. Times are formatted as RFC 3339.
+func (r *rumReport) csv() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	_, keys, rows := r.rows(by)
+	w.Write(keys)
+	for _, row := range rows {
+		record := []string{}
+		for _, cell := range row {
+			switch value := cell.(type) {
+			case nil:
+				record = append(record, "")
+			case time.Time:
+				record = append(record, value.Format(time.RFC3339))
+			default:
+				record = append(record, fmt.Sprint(value))
+			}
+		}
+		w.Write(record)
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Main function for `tfm report rum`
+func reportRUM(c tfclient.ClientContexts) error {
+	o.AddMessageUserProvided("Counting the resources under management of", c.SourceHostname+"/"+c.SourceOrganizationName)
+
+	workspaces, err := helper.GetWorkspacesCfg(c.SourceClient, c.SourceContext, c.SourceOrganizationName, "source")
+	if err != nil {
+		return err
+	}
+
+	o.AddFormattedMessageCalculated("Found %d Workspaces", len(workspaces))
+
+	projectNames, err := getProjectNames(c)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list the projects of "+c.SourceOrganizationName)
+	}
+
+	r := &rumReport{
+		Hostname:      c.SourceHostname,
+		GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+		Workspaces:    []workspaceRUM{},
+		Projects:      []projectRUM{},
+		Organizations: []organizationRUM{},
+	}
+
+	for _, ws := range workspaces {
+		o.AddMessageUserProvided("Counting the resources of workspace", ws.Name)
+
+		rum, lastUpdated, err := countWorkspaceRUM(c, ws)
+		if err != nil {
+			return errors.Wrap(err, "Failed to count the resources of workspace "+ws.Name)
+		}
+
+		wsRUM := workspaceRUM{
+			Organization: c.SourceOrganizationName,
+			Workspace:    ws.Name,
+			WorkspaceID:  ws.ID,
+			RUM:          rum,
+			LastUpdated:  lastUpdated,
+		}
+		if ws.Project != nil {
+			wsRUM.ProjectID = ws.Project.ID
+			wsRUM.Project = projectNames[ws.Project.ID]
+		}
+		r.Workspaces = append(r.Workspaces, wsRUM)
+	}
+
+	r.aggregate()
+
+	var data []byte
+	switch format {
+	case formatTable:
+		headers, _, rows := r.rows(by)
+		if outputFile == "" {
+			o.AddTableHeaders(headers...)
+			for _, row := range rows {
+				o.AddTableRows(formatCells(row)...)
+			}
+			addTotals(r)
+			return nil
+		}
+		data = []byte(renderTable(headers, rows))
+
+	case formatCSV:
+		data, err = r.csv()
+		if err != nil {
+			return errors.Wrap(err, "Failed to write the report as CSV")
+		}
+
+	case formatJSON:
+		data, err = json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "Failed to marshal the report to JSON")
+		}
+		data = append(data, '\n')
+	}
+
+	if outputFile == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(outputFile, data, 0644); err != nil {
+		return errors.Wrap(err, "Failed to write "+outputFile)
+	}
+
+	addTotals(r)
+	o.AddDeferredMessageRead("Report written to", outputFile)
+
+	return nil
+}
+
+// Adds the totals of the report to the deferred messages
+func addTotals(r *rumReport) {
+	total := tfstate.RUM{}
+	for _, org := range r.Organizations {
+		total.Add(org.RUM)
+	}
+
+	o.AddDeferredMessageRead("Workspaces", len(r.Workspaces))
+	o.AddDeferredMessageRead("Resources under management", total.Managed)
+	o.AddDeferredMessageRead("Data sources (not counted)", total.DataSources)
+	o.AddDeferredMessageRead("Placeholders (not counted)", total.Placeholders)
+}
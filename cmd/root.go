@@ -24,6 +24,8 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/hashicorp-services/tfm/cmd/assess"
+	"github.com/hashicorp-services/tfm/cmd/backup"
 	"github.com/hashicorp-services/tfm/cmd/copy"
 	"github.com/hashicorp-services/tfm/cmd/core"
 	"github.com/hashicorp-services/tfm/cmd/delete"
@@ -32,7 +34,10 @@ import (
 	"github.com/hashicorp-services/tfm/cmd/list"
 	"github.com/hashicorp-services/tfm/cmd/lock"
 	// "github.com/hashicorp-services/tfm/cmd/nuke"
+	"github.com/hashicorp-services/tfm/cmd/preflight"
+	"github.com/hashicorp-services/tfm/cmd/report"
 	"github.com/hashicorp-services/tfm/cmd/unlock"
+	"github.com/hashicorp-services/tfm/cmd/verify"
 	"github.com/hashicorp-services/tfm/output"
 	"github.com/hashicorp-services/tfm/version"
 	"github.com/logrusorgru/aurora"
@@ -59,12 +64,15 @@ var (
 
 // rootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-	Use:              "tfm",
-	Short:            "A CLI to assist with Terraform community edition, Terraform Cloud, and Terraform Enterprise migrations.",
-	SilenceUsage:     true,
-	SilenceErrors:    true,
-	Version:          version.String(),
-	PersistentPreRun: bindPFlags, // Bind here to avoid having to call this in every subcommand
+	Use:           "tfm",
+	Short:         "A CLI to assist with Terraform community edition, Terraform Cloud, and Terraform Enterprise migrations.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Version:       version.String(),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		bindPFlags(cmd, args) // Bind here to avoid having to call this in every subcommand
+		return preflight.Run(cmd)
+	},
 }
 
 // `tfemig copy` commands
@@ -92,6 +100,7 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Config file, can be used to store common flags, (default is ~/.tfm.hcl).")
 	RootCmd.PersistentFlags().BoolP("autoapprove", "", false, "Auto approve the tfm run. --autoapprove=true . false by default")
 	RootCmd.PersistentFlags().BoolVar(&jsonOut, "json", false, "Print the output in JSON format")
+	RootCmd.PersistentFlags().Bool("skip-preflight", false, "Skip the preflight checks of token permissions, entitlements and limits")
 
 	// Available commands required after "tfm"
 	RootCmd.AddCommand(copy.CopyCmd)
@@ -102,6 +111,13 @@ func init() {
 	RootCmd.AddCommand(lock.LockCmd)
 	RootCmd.AddCommand(unlock.UnlockCmd)
 	RootCmd.AddCommand(core.CoreCmd)
+	RootCmd.AddCommand(backup.BackupCmd)
+	RootCmd.AddCommand(backup.RestoreCmd)
+	RootCmd.AddCommand(copy.CutoverCmd)
+	RootCmd.AddCommand(verify.VerifyCmd)
+	RootCmd.AddCommand(assess.AssessCmd)
+	RootCmd.AddCommand(report.ReportCmd)
+	RootCmd.AddCommand(preflight.PreflightCmd)
 	// Turn off completion option
 	RootCmd.CompletionOptions.DisableDefaultCmd = true
 
@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package verify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// All functions related to verifying destination workspaces with plan-only runs
+
+// The results of a plan-only run
+const (
+	planErrored  = "errored"
+	planChanges  = "changes"
+	planNoOp     = "no-op"
+	planTimedOut = "timed out"
+)
+
+// How often a plan-only run is read while it runs
+const planPollInterval = 10 * time.Second
+
+// The result of the plan-only run of a workspace
+type planResult struct {
+	workspace string
+	runID     string
+	url       string
+	result    string
+	plan      *tfe.Plan
+	err       error
+}
+
+// Returns the URL of a run in the destination
+func runURL(c tfclient.ClientContexts, workspace string, runID string) string {
+	return fmt.Sprintf("https://%s/app/%s/workspaces/%s/runs/%s", c.DestinationHostname, c.DestinationOrganizationName, workspace, runID)
+}
+
+// Stops a plan-only run that did not finish in time so it does not hold up the workspace queue.
+// A run that is planning is canceled, a run that has not started planning is discarded.
+func stopRun(c tfclient.ClientContexts, run *tfe.Run) error {
+	err := c.DestinationClient.Runs.Cancel(c.DestinationContext, run.ID, tfe.RunCancelOptions{})
+	if err != nil {
+		err = c.DestinationClient.Runs.Discard(c.DestinationContext, run.ID, tfe.RunDiscardOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Stopped plan-only run %v that was %v\n", run.ID, run.Status)
+	return nil
+}
+
+// Queues a plan-only run on a destination workspace and waits for it to finish
+func runPlan(c tfclient.ClientContexts, ws *tfe.Workspace) planResult {
+	res := planResult{workspace: ws.Name}
+
+	message := "tfm verify --plan"
+	run, err := c.DestinationClient.Runs.Create(c.DestinationContext, tfe.RunCreateOptions{
+		Workspace: ws,
+		PlanOnly:  tfe.Bool(true),
+		Message:   &message,
+	})
+	if err != nil {
+		res.result = planErrored
+		res.err = errors.Wrap(err, "failed to queue plan-only run")
+		return res
+	}
+
+	res.runID = run.ID
+	res.url = runURL(c, ws.Name, run.ID)
+	fmt.Printf("Queued plan-only run %v on workspace %v\n", run.ID, ws.Name)
+
+	deadline := time.Now().Add(runTimeout)
+	for {
+		run, err = c.DestinationClient.Runs.ReadWithOptions(c.DestinationContext, res.runID, &tfe.RunReadOptions{
+			Include: []tfe.RunIncludeOpt{tfe.RunPlan},
+		})
+		if err != nil {
+			res.result = planErrored
+			res.err = errors.Wrap(err, "failed to read plan-only run")
+			return res
+		}
+
+		if helper.RunFinished(run) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			res.result = planTimedOut
+			res.err = fmt.Errorf("run is %v after %v", run.Status, runTimeout)
+			if err := stopRun(c, run); err != nil {
+				res.err = fmt.Errorf("%v, unable to stop the run: %v", res.err, err)
+			}
+			return res
+		}
+
+		time.Sleep(planPollInterval)
+	}
+
+	res.plan = run.Plan
+
+	switch {
+	case run.Status == tfe.RunErrored:
+		res.result = planErrored
+		res.err = errors.New("plan errored")
+	case run.Status != tfe.RunPlannedAndFinished:
+		res.result = string(run.Status)
+		res.err = fmt.Errorf("run is %v", run.Status)
+	case run.HasChanges || (run.Plan != nil && run.Plan.HasChanges):
+		res.result = planChanges
+	default:
+		res.result = planNoOp
+	}
+
+	fmt.Printf("Plan-only run %v on workspace %v: %v\n", run.ID, ws.Name, res.result)
+
+	return res
+}
+
+// Main function for `tfm verify --plan`
+func verifyPlans(cmd *cobra.Command, c tfclient.ClientContexts) error {
+	workspaces, err := getDstWorkspacesCfg(cmd, c)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Workspaces from destination")
+	}
+
+	o.AddMessageUserProvided2(len(workspaces), "workspaces will be verified with plan-only runs in", c.DestinationHostname)
+
+	// Queue at most `--concurrency` runs at the same time
+	results := make([]planResult, len(workspaces))
+	limit := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, ws := range workspaces {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, ws *tfe.Workspace) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			results[i] = runPlan(c, ws)
+		}(i, ws)
+	}
+	wg.Wait()
+
+	o.AddTableHeaders("Workspace", "Result", "Add", "Change", "Destroy", "Import", "Run URL")
+
+	counts := map[string]int{}
+	var failed []interface{}
+
+	for _, r := range results {
+		counts[r.result]++
+
+		if r.plan != nil {
+			o.AddTableRows(r.workspace, r.result, r.plan.ResourceAdditions, r.plan.ResourceChanges, r.plan.ResourceDestructions, r.plan.ResourceImports, r.url)
+		} else {
+			o.AddTableRows(r.workspace, r.result, "", "", "", "", r.url)
+		}
+
+		if r.err != nil {
+			failed = append(failed, fmt.Sprintf("%v: %v", r.workspace, r.err))
+		}
+	}
+
+	// Runs that were canceled, discarded or stopped by a policy check count as other
+	other := len(results) - counts[planNoOp] - counts[planChanges] - counts[planErrored] - counts[planTimedOut]
+	o.AddFormattedMessageUserProvided3("Plans with no changes: %v, with changes: %v, errored: %v", counts[planNoOp], counts[planChanges], counts[planErrored])
+	o.AddFormattedMessageUserProvided2("Plans timed out: %v, other: %v", counts[planTimedOut], other)
+
+	if len(failed) > 0 {
+		o.AddDeferredListMessageRead("Plans that errored or did not finish", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package verify
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp-services/tfm/cmd/helper"
+	"github.com/hashicorp-services/tfm/output"
+	"github.com/hashicorp-services/tfm/tfclient"
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// `tfm verify` command
+var (
+	o           output.Output
+	plan        bool
+	concurrency int
+	runTimeout  time.Duration
+
+	VerifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verify migrated workspaces",
+		Long:  "Verifies that migrated workspaces work in the destination",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !plan {
+				return errors.New("nothing to verify, use the --plan flag")
+			}
+			if concurrency < 1 {
+				return errors.New("--concurrency must be at least 1")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyPlans(cmd, tfclient.GetClientContexts())
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			o.Close()
+		},
+	}
+)
+
+func init() {
+	VerifyCmd.Flags().BoolVarP(&plan, "plan", "", false, "Queue a plan-only run on each destination workspace and report the result")
+	VerifyCmd.Flags().IntVarP(&concurrency, "concurrency", "", 5, "The maximum number of plan-only runs queued at the same time")
+	VerifyCmd.Flags().DurationVarP(&runTimeout, "run-timeout", "", 30*time.Minute, "How long to wait for a plan-only run to finish")
+}
+
+// Gets the destination workspaces from the `workspaces` list or the values of `workspaces-map`
+// in the config file. If neither is configured all destination workspaces are returned after
+// a confirmation.
+func getDstWorkspacesCfg(cmd *cobra.Command, c tfclient.ClientContexts) ([]*tfe.Workspace, error) {
+	wsList, err := helper.GetWorkspaceNamesCfg("destination")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wsList) == 0 {
+		o.AddMessageUserProvided2("\nWarning:\n\n", "ALL WORKSPACES WILL BE VERIFIED in", c.DestinationHostname)
+
+		if !confirm(cmd) {
+			fmt.Println("\n\n**** Canceling tfm run **** ")
+			os.Exit(1)
+		}
+	}
+
+	return helper.GetWorkspacesCfg(c.DestinationClient, c.DestinationContext, c.DestinationOrganizationName, "destination")
+}
+
+// Asks the user to confirm an operation unless --autoapprove is set
+func confirm(cmd *cobra.Command) bool {
+
+	var input string
+
+	fmt.Printf("Do you want to continue with this operation? [y|n]: ")
+
+	auto, err := cmd.Flags().GetBool("autoapprove")
+
+	if err != nil {
+		fmt.Println("Error Retrieving autoapprove flag value: ", err)
+	}
+
+	// Check if --autoapprove=false
+	if !auto {
+		_, err := fmt.Scanln(&input)
+		if err != nil {
+			return false
+		}
+	} else {
+		input = "y"
+		fmt.Println("y(autoapprove=true)")
+	}
+
+	input = strings.ToLower(input)
+
+	if input == "y" || input == "yes" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CommandProvider runs an external command for each sensitive variable. The command is
+// called with the owner and key as arguments, and TFM_SECRET_OWNER and TFM_SECRET_KEY set
+// in its environment. The value is read from stdout with the trailing newline removed.
+// An exit code of 0 with empty output means the command has no value for the variable.
+type CommandProvider struct {
+	// The executable followed by its arguments
+	command []string
+}
+
+func NewCommandProvider(command []string) (*CommandProvider, error) {
+	if len(command) == 0 || strings.TrimSpace(command[0]) == "" {
+		return nil, errors.New("secrets-command must be set when using the command secrets provider")
+	}
+	return &CommandProvider{command: command}, nil
+}
+
+func (p *CommandProvider) Name() string {
+	return "command"
+}
+
+func (p *CommandProvider) Get(owner string, key string) (string, bool, error) {
+	args := append(append([]string{}, p.command[1:]...), owner, key)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.command[0], args...)
+	cmd.Env = append(os.Environ(), "TFM_SECRET_OWNER="+owner, "TFM_SECRET_KEY="+key)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", false, fmt.Errorf("secrets command failed for %v/%v: %v %v", owner, key, err, strings.TrimSpace(stderr.String()))
+	}
+
+	value := strings.TrimSuffix(stdout.String(), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	if value == "" {
+		return "", false, nil
+	}
+
+	return value, true, nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package secrets
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+var envNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// EnvProvider reads sensitive values from environment variables named
+// TFM_SECRET_<OWNER>_<KEY>. Owner and key are upper cased and any character
+// that is not a letter or digit is replaced with an underscore.
+type EnvProvider struct{}
+
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{}
+}
+
+func (p *EnvProvider) Name() string {
+	return "env"
+}
+
+// EnvName returns the environment variable name for an owner and key
+func EnvName(owner string, key string) string {
+	name := "TFM_SECRET_" + owner + "_" + key
+	return strings.ToUpper(envNameInvalidChars.ReplaceAllString(name, "_"))
+}
+
+func (p *EnvProvider) Get(owner string, key string) (string, bool, error) {
+	value, ok := os.LookupEnv(EnvName(owner, key))
+	return value, ok, nil
+}
@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Environment variable holding the passphrase of an encrypted secrets file
+const PassphraseEnv = "TFM_SECRETS_PASSPHRASE"
+
+// Encryption scheme written to encrypted secrets files
+const fileEncryption = "scrypt-aes-256-gcm"
+
+// Envelope of an encrypted secrets file
+type encryptedFile struct {
+	Encryption string `json:"encryption"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileProvider reads sensitive values from a local JSON file in the format
+// {"<owner>": {"<key>": "<value>"}}. The file can be plain JSON or encrypted with
+// `tfm generate secrets-file`, in which case the passphrase is read from TFM_SECRETS_PASSPHRASE.
+type FileProvider struct {
+	path    string
+	secrets map[string]map[string]string
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	if path == "" {
+		return nil, errors.New("secrets-file must be set when using the file secrets provider")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = decryptFile(content, os.Getenv(PassphraseEnv))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets file %v: %v", path, err)
+	}
+
+	secrets := map[string]map[string]string{}
+	if err := json.Unmarshal(content, &secrets); err != nil {
+		return nil, fmt.Errorf("unable to read secrets file %v: %v", path, err)
+	}
+
+	return &FileProvider{path: path, secrets: secrets}, nil
+}
+
+func (p *FileProvider) Name() string {
+	return "file " + p.path
+}
+
+func (p *FileProvider) Get(owner string, key string) (string, bool, error) {
+	value, ok := p.secrets[owner][key]
+	return value, ok, nil
+}
+
+// Derives the AES-256 key of an encrypted secrets file from the passphrase
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 32768, 8, 1, 32)
+}
+
+// EncryptFile encrypts the content of a plain secrets file with a passphrase
+func EncryptFile(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New(PassphraseEnv + " must be set to encrypt a secrets file")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(encryptedFile{
+		Encryption: fileEncryption,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+// Returns the plain content of a secrets file. Plain files are returned as they are.
+func decryptFile(content []byte, passphrase string) ([]byte, error) {
+	envelope := encryptedFile{}
+	if err := json.Unmarshal(content, &envelope); err != nil || envelope.Encryption == "" {
+		return content, nil
+	}
+
+	if envelope.Encryption != fileEncryption {
+		return nil, fmt.Errorf("unsupported encryption %q", envelope.Encryption)
+	}
+
+	if passphrase == "" {
+		return nil, errors.New("file is encrypted and " + PassphraseEnv + " is not set")
+	}
+
+	key, err := deriveKey(passphrase, envelope.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("unable to decrypt, check the passphrase")
+	}
+
+	return plaintext, nil
+}
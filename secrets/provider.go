@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Provider returns the values of sensitive variables. The API never returns sensitive
+// values, so these are looked up by the owning workspace or variable set name and the variable key.
+type Provider interface {
+	// Name of the provider used in output
+	Name() string
+
+	// Get returns the value of a sensitive variable and false if the provider has no value for it
+	Get(owner string, key string) (string, bool, error)
+}
+
+// NewProvider creates the secret provider of the given kind. Provider settings are read from
+// the config file. An empty kind returns a nil provider.
+func NewProvider(kind string) (Provider, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case "file":
+		return NewFileProvider(viper.GetString("secrets-file"))
+	case "env":
+		return NewEnvProvider(), nil
+	case "command":
+		// A list keeps arguments with spaces, a string is split on whitespace
+		return NewCommandProvider(viper.GetStringSlice("secrets-command"))
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q, expected file, env or command", kind)
+	}
+}
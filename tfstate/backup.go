@@ -0,0 +1,193 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"github.com/spf13/viper"
+)
+
+// The name of the manifest file in a backup directory
+const ManifestFile = "manifest.json"
+
+// The directory backups are written to when none is configured
+const DefaultBackupDir = "state-backups"
+
+// Manifest describes the state files in a backup directory
+type Manifest struct {
+	Side         string          `json:"side"`
+	Hostname     string          `json:"hostname"`
+	Organization string          `json:"organization"`
+	CreatedAt    time.Time       `json:"created-at"`
+	States       []ManifestEntry `json:"states"`
+}
+
+// ManifestEntry is a backed up state version
+type ManifestEntry struct {
+	Workspace        string    `json:"workspace"`
+	WorkspaceID      string    `json:"workspace-id"`
+	StateVersionID   string    `json:"state-version-id"`
+	Serial           int64     `json:"serial"`
+	Lineage          string    `json:"lineage"`
+	MD5              string    `json:"md5"`
+	TerraformVersion string    `json:"terraform-version"`
+	CreatedAt        time.Time `json:"created-at"`
+	Current          bool      `json:"current"`
+	// The state file path relative to the backup directory
+	File string `json:"file"`
+}
+
+// Backup writes state versions of one side to a backup directory
+type Backup struct {
+	Dir      string
+	Manifest Manifest
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// BackupDir returns the directory to write backups in, the given directory or
+// `state-backup-dir` from the config file or the default directory
+func BackupDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	if dir := viper.GetString("state-backup-dir"); dir != "" {
+		return dir
+	}
+	return DefaultBackupDir
+}
+
+// NewBackup creates a backup directory named after the side, organization and time
+// inside the parent directory
+func NewBackup(parent string, side string, hostname string, organization string) (*Backup, error) {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s-%s", side, unsafePathChars.ReplaceAllString(organization, "_"), now.Format("20060102T150405Z"))
+	dir := filepath.Join(parent, name)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &Backup{
+		Dir: dir,
+		Manifest: Manifest{
+			Side:         side,
+			Hostname:     hostname,
+			Organization: organization,
+			CreatedAt:    now,
+			States:       []ManifestEntry{},
+		},
+	}, nil
+}
+
+// Add downloads a state version into the workspace directory of the backup and
+// writes the manifest, so the manifest is complete if a later download fails
+func (b *Backup) Add(ctx context.Context, client *tfe.Client, workspace *tfe.Workspace, sv *tfe.StateVersion, current bool) (*ManifestEntry, error) {
+	wsDir := unsafePathChars.ReplaceAllString(workspace.Name, "_")
+	if err := os.MkdirAll(filepath.Join(b.Dir, wsDir), 0o700); err != nil {
+		return nil, err
+	}
+
+	f, err := Download(ctx, client, sv.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Remove()
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ParseReader(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := ManifestEntry{
+		Workspace:        workspace.Name,
+		WorkspaceID:      workspace.ID,
+		StateVersionID:   sv.ID,
+		Serial:           parsed.Serial,
+		Lineage:          parsed.Lineage,
+		MD5:              f.MD5,
+		TerraformVersion: parsed.TerraformVersion,
+		CreatedAt:        sv.CreatedAt,
+		Current:          current,
+		File:             filepath.Join(wsDir, fmt.Sprintf("serial-%d-%s.tfstate", parsed.Serial, sv.ID)),
+	}
+
+	if err := moveFile(f.Path, filepath.Join(b.Dir, entry.File)); err != nil {
+		return nil, err
+	}
+
+	b.Manifest.States = append(b.Manifest.States, entry)
+	if err := b.WriteManifest(); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// WriteManifest writes the manifest file of the backup
+func (b *Backup) WriteManifest() error {
+	data, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(b.Dir, ManifestFile), data, 0o600)
+}
+
+// ReadManifest reads the manifest file of a backup directory
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read backup manifest: %v", err)
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("unable to parse backup manifest: %v", err)
+	}
+
+	for _, e := range m.States {
+		if strings.Contains(e.File, "..") || filepath.IsAbs(e.File) {
+			return nil, fmt.Errorf("invalid state file path %q in backup manifest", e.File)
+		}
+	}
+
+	return m, nil
+}
+
+// Moves a file, copying it when the temp directory is on another file system
+func moveFile(from string, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
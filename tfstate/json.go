@@ -0,0 +1,307 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The format version of the JSON state representation, see
+// https://developer.hashicorp.com/terraform/internals/json-format#state-representation
+const JSONFormatVersion = "1.0"
+
+// The largest state a JSON state is generated for. Generating the JSON state holds the state
+// and its JSON representation in memory, larger states are uploaded without it.
+const MaxJSONStateSize = 64 << 20
+
+// jsonOutput is an output in the JSON state representation
+type jsonOutput struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+}
+
+type jsonState struct {
+	FormatVersion    string      `json:"format_version"`
+	TerraformVersion string      `json:"terraform_version,omitempty"`
+	Values           *jsonValues `json:"values,omitempty"`
+}
+
+type jsonValues struct {
+	Outputs    map[string]jsonOutput `json:"outputs,omitempty"`
+	RootModule jsonModule            `json:"root_module"`
+}
+
+type jsonModule struct {
+	Resources    []jsonResource `json:"resources,omitempty"`
+	Address      string         `json:"address,omitempty"`
+	ChildModules []*jsonModule  `json:"child_modules,omitempty"`
+}
+
+type jsonResource struct {
+	Address         string          `json:"address"`
+	Mode            string          `json:"mode"`
+	Type            string          `json:"type"`
+	Name            string          `json:"name"`
+	Index           json.RawMessage `json:"index,omitempty"`
+	ProviderName    string          `json:"provider_name"`
+	SchemaVersion   uint64          `json:"schema_version"`
+	AttributeValues json.RawMessage `json:"values,omitempty"`
+	SensitiveValues json.RawMessage `json:"sensitive_values,omitempty"`
+	DependsOn       []string        `json:"depends_on,omitempty"`
+	Tainted         bool            `json:"tainted,omitempty"`
+	DeposedKey      string          `json:"deposed_key,omitempty"`
+}
+
+// stateResources holds the resources of a version 4 state file
+type stateResources struct {
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Provider  string `json:"provider"`
+		Instances []struct {
+			IndexKey      json.RawMessage `json:"index_key"`
+			Status        string          `json:"status"`
+			Deposed       string          `json:"deposed"`
+			SchemaVersion uint64          `json:"schema_version"`
+			Attributes    json.RawMessage `json:"attributes"`
+			Sensitive     []sensitivePath `json:"sensitive_attributes"`
+			Dependencies  []string        `json:"dependencies"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// JSONOutputs returns the root module outputs in the JSON state representation
+func JSONOutputs(s *State) ([]byte, error) {
+	return json.Marshal(jsonOutputs(s))
+}
+
+func jsonOutputs(s *State) map[string]jsonOutput {
+	outputs := map[string]jsonOutput{}
+	for name, output := range s.Outputs {
+		outputs[name] = jsonOutput{
+			Sensitive: output.Sensitive,
+			Type:      output.Type,
+			Value:     output.Value,
+		}
+	}
+	return outputs
+}
+
+// A path to a sensitive attribute of a resource instance, a list of steps such as
+// {"type":"get_attr","value":"password"} or {"type":"index","value":{"value":0,"type":"number"}}
+type sensitivePath []struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// A tree of the sensitive attribute paths of a resource instance. Steps are keyed by
+// attribute name, map key or list index.
+type sensitiveNode struct {
+	sensitive bool
+	steps     map[string]*sensitiveNode
+}
+
+// Builds the tree of the sensitive attribute paths of a resource instance
+func newSensitiveTree(paths []sensitivePath) *sensitiveNode {
+	root := &sensitiveNode{}
+	for _, path := range paths {
+		node := root
+		for _, step := range path {
+			key := ""
+			switch step.Type {
+			case "get_attr":
+				json.Unmarshal(step.Value, &key)
+			case "index":
+				index := struct {
+					Value interface{} `json:"value"`
+				}{}
+				json.Unmarshal(step.Value, &index)
+				key = fmt.Sprint(index.Value)
+			}
+
+			if node.steps == nil {
+				node.steps = map[string]*sensitiveNode{}
+			}
+			if node.steps[key] == nil {
+				node.steps[key] = &sensitiveNode{}
+			}
+			node = node.steps[key]
+		}
+		node.sensitive = true
+	}
+	return root
+}
+
+// Returns the step of a node, nil if no sensitive path goes through it
+func (n *sensitiveNode) step(key string) *sensitiveNode {
+	if n == nil {
+		return nil
+	}
+	return n.steps[key]
+}
+
+// Returns the sensitive values of an attribute value the way Terraform marks them: true for
+// a sensitive value, objects and lists mirroring the attribute value for containers and false
+// for other values. Object attributes that are false are left out.
+func sensitiveValues(value interface{}, node *sensitiveNode) interface{} {
+	if node != nil && node.sensitive {
+		return true
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		values := map[string]interface{}{}
+		for key, attr := range v {
+			if sv := sensitiveValues(attr, node.step(key)); sv != false {
+				values[key] = sv
+			}
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, element := range v {
+			values[i] = sensitiveValues(element, node.step(fmt.Sprint(i)))
+		}
+		return values
+	}
+	return false
+}
+
+// Returns the sensitive_values of a resource instance from its attributes and the paths of
+// its sensitive_attributes
+func instanceSensitiveValues(attributes json.RawMessage, paths []sensitivePath) (json.RawMessage, error) {
+	var value interface{}
+	if len(attributes) > 0 {
+		if err := json.Unmarshal(attributes, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	values := sensitiveValues(value, newSensitiveTree(paths))
+	if values == false {
+		values = map[string]interface{}{}
+	}
+	return json.Marshal(values)
+}
+
+// JSONState returns the JSON state representation of a state file, the same
+// representation `terraform show -json` produces. Only version 4 state files
+// can be converted. The `sensitive_values` of each resource are built from the
+// `sensitive_attributes` of its instance.
+func JSONState(data []byte) ([]byte, error) {
+	s, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if s.Version != 4 {
+		return nil, fmt.Errorf("the JSON state can not be generated from a version %d state", s.Version)
+	}
+
+	raw := stateResources{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse state resources: %v", err)
+	}
+
+	root := &jsonModule{}
+	modules := map[string]*jsonModule{"": root}
+
+	for _, r := range raw.Resources {
+		module := getJSONModule(modules, r.Module)
+
+		mode := "managed"
+		prefix := ""
+		if r.Mode == "data" {
+			mode = "data"
+			prefix = "data."
+		}
+
+		for _, i := range r.Instances {
+			address := prefix + r.Type + "." + r.Name
+			if len(i.IndexKey) > 0 {
+				address += "[" + string(i.IndexKey) + "]"
+			}
+			if r.Module != "" {
+				address = r.Module + "." + address
+			}
+
+			sensitive, err := instanceSensitiveValues(i.Attributes, i.Sensitive)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse attributes of %v: %v", address, err)
+			}
+
+			module.Resources = append(module.Resources, jsonResource{
+				Address:         address,
+				Mode:            mode,
+				Type:            r.Type,
+				Name:            r.Name,
+				Index:           i.IndexKey,
+				ProviderName:    providerName(r.Provider),
+				SchemaVersion:   i.SchemaVersion,
+				AttributeValues: i.Attributes,
+				SensitiveValues: sensitive,
+				DependsOn:       i.Dependencies,
+				Tainted:         i.Status == "tainted",
+				DeposedKey:      i.Deposed,
+			})
+		}
+	}
+
+	sortJSONModule(root)
+
+	return json.Marshal(jsonState{
+		FormatVersion:    JSONFormatVersion,
+		TerraformVersion: s.TerraformVersion,
+		Values: &jsonValues{
+			Outputs:    jsonOutputs(s),
+			RootModule: *root,
+		},
+	})
+}
+
+// Returns the module with the given address, creating it and its parents if needed
+func getJSONModule(modules map[string]*jsonModule, address string) *jsonModule {
+	if m, ok := modules[address]; ok {
+		return m
+	}
+
+	// The parent of module.a.module.b is module.a
+	parentAddress := ""
+	if i := strings.LastIndex(address, ".module."); i >= 0 {
+		parentAddress = address[:i]
+	}
+	parent := getJSONModule(modules, parentAddress)
+
+	m := &jsonModule{Address: address}
+	parent.ChildModules = append(parent.ChildModules, m)
+	modules[address] = m
+
+	return m
+}
+
+// Sorts child modules by address so the representation is stable
+func sortJSONModule(m *jsonModule) {
+	sort.Slice(m.ChildModules, func(i, j int) bool {
+		return m.ChildModules[i].Address < m.ChildModules[j].Address
+	})
+	for _, child := range m.ChildModules {
+		sortJSONModule(child)
+	}
+}
+
+// Returns the provider source address of a state provider configuration, for example
+// registry.terraform.io/hashicorp/aws for provider["registry.terraform.io/hashicorp/aws"].east
+func providerName(provider string) string {
+	start := strings.Index(provider, "[\"")
+	end := strings.LastIndex(provider, "\"]")
+	if start < 0 || end <= start {
+		return provider
+	}
+	return provider[start+2 : end]
+}
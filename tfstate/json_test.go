@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONState(t *testing.T) {
+	state := `{
+  "version": 4,
+  "terraform_version": "1.6.0",
+  "serial": 3,
+  "lineage": "x",
+  "outputs": {
+    "password": {"value": "secret", "type": "string", "sensitive": true}
+  },
+  "resources": [
+    {
+      "module": "module.db",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "provider": "module.db.provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {
+          "index_key": 0,
+          "schema_version": 2,
+          "attributes": {"id": "db-1", "password": "secret", "tags": {"env": "prod"}, "ports": [5432, 5433]},
+          "sensitive_attributes": [
+            [{"type": "get_attr", "value": "password"}],
+            [{"type": "get_attr", "value": "ports"}, {"type": "index", "value": {"value": 1, "type": "number"}}]
+          ],
+          "dependencies": ["module.db.aws_vpc.main"]
+        }
+      ]
+    }
+  ]
+}`
+
+	data, err := JSONState([]byte(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := jsonState{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("unable to parse JSON state: %v", err)
+	}
+
+	if s.FormatVersion != JSONFormatVersion || s.TerraformVersion != "1.6.0" {
+		t.Errorf("unexpected format version %v or terraform version %v", s.FormatVersion, s.TerraformVersion)
+	}
+	if !s.Values.Outputs["password"].Sensitive {
+		t.Errorf("expected the password output to be sensitive")
+	}
+	if len(s.Values.RootModule.ChildModules) != 1 || s.Values.RootModule.ChildModules[0].Address != "module.db" {
+		t.Fatalf("expected child module module.db, got %+v", s.Values.RootModule.ChildModules)
+	}
+
+	r := s.Values.RootModule.ChildModules[0].Resources[0]
+	if r.Address != "module.db.aws_db_instance.main[0]" {
+		t.Errorf("unexpected address %v", r.Address)
+	}
+	if r.ProviderName != "registry.terraform.io/hashicorp/aws" {
+		t.Errorf("unexpected provider name %v", r.ProviderName)
+	}
+	if r.SchemaVersion != 2 {
+		t.Errorf("unexpected schema version %v", r.SchemaVersion)
+	}
+	if string(r.SensitiveValues) != `{"password":true,"ports":[false,true],"tags":{}}` {
+		t.Errorf("unexpected sensitive values %s", r.SensitiveValues)
+	}
+}
+
+func TestJSONStateVersion3(t *testing.T) {
+	if _, err := JSONState([]byte(`{"version": 3, "serial": 1, "lineage": "x", "modules": []}`)); err == nil {
+		t.Fatalf("expected an error for a version 3 state")
+	}
+}
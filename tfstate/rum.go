@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Resource types that do not count as resources under management in HCP Terraform billing
+var PlaceholderTypes = []string{"null_resource", "terraform_data"}
+
+// RUM holds the resource instances of a state counted by the HCP Terraform billing rules
+type RUM struct {
+	// Managed resource instances, the resources under management
+	Managed int `json:"rum"`
+	// Data source instances, which are not billed
+	DataSources int `json:"dataSources"`
+	// Instances of placeholder resources such as null_resource, which are not billed
+	Placeholders int `json:"placeholders"`
+}
+
+// Adds the counts of another state
+func (r *RUM) Add(other RUM) {
+	r.Managed += other.Managed
+	r.DataSources += other.DataSources
+	r.Placeholders += other.Placeholders
+}
+
+// A resource of a state format 4 resources array, without the attributes of its instances
+type rumResource struct {
+	Mode      string        `json:"mode"`
+	Type      string        `json:"type"`
+	Instances []rumInstance `json:"instances"`
+}
+
+// An instance of a state format 4 resource. Deposed objects are create_before_destroy
+// leftovers of the same instance, they are not counted.
+type rumInstance struct {
+	Deposed string `json:"deposed"`
+}
+
+// A resource of a state format 3 module, keyed by its address in the module
+type rumModuleResource struct {
+	Type string `json:"type"`
+}
+
+// Returns true for resource types that are not billed
+func isPlaceholder(resourceType string) bool {
+	for _, t := range PlaceholderTypes {
+		if resourceType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts the resource instances of a state by mode and type. Each instance of a resource with
+// count or for_each is a resource. Resources are read one at a time.
+func CountRUM(r io.Reader) (*RUM, error) {
+	rum := &RUM{}
+	d := json.NewDecoder(r)
+
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("unable to parse state: expected a JSON object")
+	}
+
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse state: %v", err)
+		}
+
+		switch t {
+		case "resources":
+			err = countRUMResources(d, rum)
+		case "modules":
+			err = countRUMModules(d, rum)
+		default:
+			err = skipValue(d)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse state: %v", err)
+		}
+	}
+
+	return rum, nil
+}
+
+// Counts the instances of a state format 4 resources array
+func countRUMResources(d *json.Decoder, rum *RUM) error {
+	if t, err := d.Token(); err != nil || t != json.Delim('[') {
+		return fmt.Errorf("expected resources to be an array")
+	}
+
+	for d.More() {
+		resource := rumResource{}
+		if err := d.Decode(&resource); err != nil {
+			return err
+		}
+
+		instances := 0
+		for _, i := range resource.Instances {
+			if i.Deposed == "" {
+				instances++
+			}
+		}
+
+		switch {
+		case resource.Mode == "data":
+			rum.DataSources += instances
+		case isPlaceholder(resource.Type):
+			rum.Placeholders += instances
+		default:
+			rum.Managed += instances
+		}
+	}
+
+	_, err := d.Token()
+	return err
+}
+
+// Counts the resources of the modules of a state format 3 modules array. Each key of a module's
+// resources is an instance, data sources are keyed with a `data.` prefix.
+func countRUMModules(d *json.Decoder, rum *RUM) error {
+	if t, err := d.Token(); err != nil || t != json.Delim('[') {
+		return fmt.Errorf("expected modules to be an array")
+	}
+
+	for d.More() {
+		if t, err := d.Token(); err != nil || t != json.Delim('{') {
+			return fmt.Errorf("expected a module to be an object")
+		}
+		for d.More() {
+			t, err := d.Token()
+			if err != nil {
+				return err
+			}
+			if t != "resources" {
+				if err := skipValue(d); err != nil {
+					return err
+				}
+				continue
+			}
+
+			resources := map[string]rumModuleResource{}
+			if err := d.Decode(&resources); err != nil {
+				return err
+			}
+			for key, resource := range resources {
+				switch {
+				case strings.HasPrefix(key, "data."):
+					rum.DataSources++
+				case isPlaceholder(resource.Type):
+					rum.Placeholders++
+				default:
+					rum.Managed++
+				}
+			}
+		}
+		if _, err := d.Token(); err != nil {
+			return err
+		}
+	}
+
+	_, err := d.Token()
+	return err
+}
@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountRUM(t *testing.T) {
+	cases := []struct {
+		name     string
+		state    string
+		expected RUM
+	}{
+		{
+			name:     "version 4",
+			state:    testState,
+			expected: RUM{Managed: 3, DataSources: 1},
+		},
+		{
+			name: "version 4 count and placeholders",
+			state: `{"version": 4, "serial": 1, "lineage": "x", "resources": [
+				{"mode": "managed", "type": "aws_instance", "name": "web", "instances": [{"index_key": 0}, {"index_key": 1}]},
+				{"mode": "managed", "type": "null_resource", "name": "wait", "instances": [{}]},
+				{"mode": "managed", "type": "terraform_data", "name": "run", "instances": [{}]}
+			]}`,
+			expected: RUM{Managed: 2, Placeholders: 2},
+		},
+		{
+			name: "version 4 deposed objects",
+			state: `{"version": 4, "serial": 1, "lineage": "x", "resources": [
+				{"mode": "managed", "type": "aws_instance", "name": "web", "instances": [{"index_key": 0}, {"index_key": 0, "deposed": "00000001"}]}
+			]}`,
+			expected: RUM{Managed: 1},
+		},
+		{
+			name: "version 3",
+			state: `{"version": 3, "serial": 1, "lineage": "x", "modules": [
+				{"path": ["root"], "resources": {"aws_instance.web": {"type": "aws_instance"}, "data.aws_ami.ubuntu": {"type": "aws_ami"}}},
+				{"path": ["root", "network"], "resources": {"aws_vpc.main": {"type": "aws_vpc"}, "null_resource.wait": {"type": "null_resource"}}}
+			]}`,
+			expected: RUM{Managed: 2, DataSources: 1, Placeholders: 1},
+		},
+		{
+			name:     "empty",
+			state:    `{"version": 4, "serial": 1, "lineage": "x", "resources": []}`,
+			expected: RUM{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rum, err := CountRUM(strings.NewReader(tc.state))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *rum != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, *rum)
+			}
+		})
+	}
+}
+
+func TestCountRUMInvalid(t *testing.T) {
+	if _, err := CountRUM(strings.NewReader(`[]`)); err == nil {
+		t.Fatalf("expected an error for a state that is not an object")
+	}
+}
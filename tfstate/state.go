@@ -0,0 +1,178 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// State format versions that can be uploaded to TFC/TFE
+var SupportedVersions = []int{3, 4}
+
+// State holds the top level attributes of a Terraform state file
+type State struct {
+	Version          int                `json:"version"`
+	TerraformVersion string             `json:"terraform_version"`
+	Serial           int64              `json:"serial"`
+	Lineage          string             `json:"lineage"`
+	Outputs          map[string]*Output `json:"outputs"`
+	// The number of resources, including data sources, counted while parsing
+	Resources int `json:"-"`
+}
+
+// Output is a root module output of a Terraform state file
+type Output struct {
+	Value     json.RawMessage `json:"value"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+// Parse reads the top level attributes of a Terraform state file and
+// rejects state format versions that are not supported.
+func Parse(data []byte) (*State, error) {
+	return ParseReader(bytes.NewReader(data))
+}
+
+// ParseReader reads the top level attributes of a Terraform state file without
+// loading the resources into memory, and rejects state format versions that are
+// not supported.
+func ParseReader(r io.Reader) (*State, error) {
+	s := &State{}
+	d := json.NewDecoder(r)
+
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("unable to parse state: expected a JSON object")
+	}
+
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse state: %v", err)
+		}
+
+		switch t {
+		case "version":
+			err = d.Decode(&s.Version)
+		case "terraform_version":
+			err = d.Decode(&s.TerraformVersion)
+		case "serial":
+			err = d.Decode(&s.Serial)
+		case "lineage":
+			err = d.Decode(&s.Lineage)
+		case "outputs":
+			err = d.Decode(&s.Outputs)
+		case "resources":
+			s.Resources, err = countResources(d)
+		case "modules":
+			s.Resources, err = countModuleResources(d)
+		default:
+			err = skipValue(d)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse state: %v", err)
+		}
+	}
+
+	supported := false
+	for _, v := range SupportedVersions {
+		if s.Version == v {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported state format version %d, supported versions are %v", s.Version, SupportedVersions)
+	}
+
+	if s.Lineage == "" {
+		return nil, fmt.Errorf("state has no lineage")
+	}
+
+	return s, nil
+}
+
+// Reads past the next JSON value one token at a time
+func skipValue(d *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// Counts the resources of a state format 4 resources array
+func countResources(d *json.Decoder) (int, error) {
+	if t, err := d.Token(); err != nil || t != json.Delim('[') {
+		return 0, fmt.Errorf("expected resources to be an array")
+	}
+
+	count := 0
+	for d.More() {
+		if err := skipValue(d); err != nil {
+			return 0, err
+		}
+		count++
+	}
+
+	_, err := d.Token()
+	return count, err
+}
+
+// Counts the resources of the modules of a state format 3 modules array
+func countModuleResources(d *json.Decoder) (int, error) {
+	if t, err := d.Token(); err != nil || t != json.Delim('[') {
+		return 0, fmt.Errorf("expected modules to be an array")
+	}
+
+	count := 0
+	for d.More() {
+		if t, err := d.Token(); err != nil || t != json.Delim('{') {
+			return 0, fmt.Errorf("expected a module to be an object")
+		}
+		for d.More() {
+			t, err := d.Token()
+			if err != nil {
+				return 0, err
+			}
+			if t != "resources" {
+				if err := skipValue(d); err != nil {
+					return 0, err
+				}
+				continue
+			}
+
+			resources := map[string]json.RawMessage{}
+			if err := d.Decode(&resources); err != nil {
+				return 0, err
+			}
+			count += len(resources)
+		}
+		if _, err := d.Token(); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err := d.Token()
+	return count, err
+}
+
+// MD5 returns the hex encoded MD5 checksum of a state file
+func MD5(data []byte) string {
+	return fmt.Sprintf("%x", md5.Sum(data))
+}
@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// The error TFC/TFE returns when a state version is created without a state
+// and the upload flow is not supported
+const uploadNotSupportedError = "param is missing or the value is empty: state"
+
+// File is a state file downloaded to a temp file
+type File struct {
+	Path string
+	MD5  string
+	Size int64
+}
+
+// Remove deletes the temp file
+func (f *File) Remove() {
+	if f != nil && f.Path != "" {
+		os.Remove(f.Path)
+	}
+}
+
+// Read returns the contents of the temp file
+func (f *File) Read() ([]byte, error) {
+	return os.ReadFile(f.Path)
+}
+
+// UploadOptions are the state and JSON state to upload as a new state version
+type UploadOptions struct {
+	// The state file to upload
+	State *File
+	// The parsed state file
+	Parsed *State
+	// Optional: The JSON state file to upload
+	JSONState *File
+	// Optional: The JSON state outputs
+	JSONOutputs []byte
+	// Force uploads a state with a different lineage than the current state
+	Force bool
+}
+
+// sizedFile lets the HTTP client send the Content-Length of a streamed file
+type sizedFile struct {
+	*os.File
+	size int64
+}
+
+func (f sizedFile) Len() int {
+	return int(f.size)
+}
+
+// Download streams a state or JSON state from a download URL to a temp file
+func Download(ctx context.Context, client *tfe.Client, url string) (*File, error) {
+	tmp, err := os.CreateTemp("", "tfm-*.tfstate")
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+
+	f := &File{Path: tmp.Name()}
+
+	hash := md5.New()
+	counter := &countWriter{}
+	if err := get(ctx, client, url, io.MultiWriter(tmp, hash, counter)); err != nil {
+		f.Remove()
+		return nil, err
+	}
+
+	f.MD5 = fmt.Sprintf("%x", hash.Sum(nil))
+	f.Size = counter.n
+
+	return f, nil
+}
+
+// DownloadMD5 streams a state from a download URL and returns its MD5 checksum
+func DownloadMD5(ctx context.Context, client *tfe.Client, url string) (string, error) {
+	hash := md5.New()
+	if err := get(ctx, client, url, hash); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// DownloadSize streams a state from a download URL and returns its size in bytes
+func DownloadSize(ctx context.Context, client *tfe.Client, url string) (int64, error) {
+	counter := &countWriter{}
+	if err := get(ctx, client, url, counter); err != nil {
+		return 0, err
+	}
+	return counter.n, nil
+}
+
+// NewFile writes a state held in memory to a temp file
+func NewFile(data []byte) (*File, error) {
+	tmp, err := os.CreateTemp("", "tfm-*.tfstate")
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+
+	f := &File{Path: tmp.Name(), MD5: MD5(data), Size: int64(len(data))}
+	if _, err := tmp.Write(data); err != nil {
+		f.Remove()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Upload creates a new state version. When the destination supports it the state version
+// is created first and the state and JSON state are streamed to its upload URLs. Otherwise
+// the state and JSON state are sent inline with the create request. Returns true if the
+// state was sent inline.
+func Upload(ctx context.Context, client *tfe.Client, workspaceID string, options UploadOptions) (*tfe.StateVersion, bool, error) {
+	createOptions := tfe.StateVersionCreateOptions{
+		Lineage: tfe.String(options.Parsed.Lineage),
+		MD5:     tfe.String(options.State.MD5),
+		Serial:  tfe.Int64(options.Parsed.Serial),
+		Force:   tfe.Bool(options.Force),
+	}
+	if options.JSONOutputs != nil {
+		createOptions.JSONStateOutputs = tfe.String(base64.StdEncoding.EncodeToString(options.JSONOutputs))
+	}
+
+	sv, err := client.StateVersions.Create(ctx, workspaceID, createOptions)
+	if err != nil {
+		if strings.Contains(err.Error(), uploadNotSupportedError) {
+			sv, err = uploadInline(ctx, client, workspaceID, createOptions, options)
+			return sv, true, err
+		}
+		return nil, false, err
+	}
+
+	if err := put(ctx, client, sv.UploadURL, options.State); err != nil {
+		return nil, false, fmt.Errorf("failed to upload state: %v", err)
+	}
+
+	if options.JSONState != nil && sv.JSONUploadURL != "" {
+		if err := put(ctx, client, sv.JSONUploadURL, options.JSONState); err != nil {
+			return nil, false, fmt.Errorf("failed to upload JSON state: %v", err)
+		}
+	}
+
+	// Read the state version again to get the download URL
+	sv, err = client.StateVersions.Read(ctx, sv.ID)
+	return sv, false, err
+}
+
+// Verify waits for an uploaded state version to be processed, downloads it and
+// compares its MD5 checksum with the uploaded state
+func Verify(ctx context.Context, client *tfe.Client, svID string, expectedMD5 string) error {
+	// Uploaded state versions are pending until the destination has processed the upload
+	var sv *tfe.StateVersion
+	for i := 0; i < 30; i++ {
+		var err error
+		sv, err = client.StateVersions.Read(ctx, svID)
+		if err != nil {
+			return fmt.Errorf("failed to read back uploaded state version: %v", err)
+		}
+		if sv.Status != tfe.StateVersionPending && sv.DownloadURL != "" {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+
+	if sv.DownloadURL == "" {
+		return fmt.Errorf("uploaded state version %v is %v and can not be downloaded", sv.ID, sv.Status)
+	}
+
+	uploadedMD5, err := DownloadMD5(ctx, client, sv.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download uploaded state version: %v", err)
+	}
+
+	if uploadedMD5 != expectedMD5 {
+		return fmt.Errorf("uploaded state version %v MD5 %v does not match the uploaded state MD5 %v", sv.ID, uploadedMD5, expectedMD5)
+	}
+
+	return nil
+}
+
+// Sends the state and JSON state base64 encoded in the create request
+func uploadInline(ctx context.Context, client *tfe.Client, workspaceID string, createOptions tfe.StateVersionCreateOptions, options UploadOptions) (*tfe.StateVersion, error) {
+	state, err := options.State.Read()
+	if err != nil {
+		return nil, err
+	}
+	createOptions.State = tfe.String(base64.StdEncoding.EncodeToString(state))
+
+	if options.JSONState != nil {
+		jsonState, err := options.JSONState.Read()
+		if err != nil {
+			return nil, err
+		}
+		createOptions.JSONState = tfe.String(base64.StdEncoding.EncodeToString(jsonState))
+	}
+
+	return client.StateVersions.Create(ctx, workspaceID, createOptions)
+}
+
+// Streams a download URL to a writer
+func get(ctx context.Context, client *tfe.Client, url string, w io.Writer) error {
+	req, err := client.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	return req.Do(ctx, w)
+}
+
+// Streams a file to an upload URL. The upload URL is signed, so the API token is not sent.
+func put(ctx context.Context, client *tfe.Client, url string, f *File) error {
+	if url == "" {
+		return errors.New("the state version has no upload URL")
+	}
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	req, err := client.NewRequest("PUT", url, sizedFile{File: file, size: f.Size})
+	if err != nil {
+		return err
+	}
+	req.Header.Del("Authorization")
+
+	return req.Do(ctx, nil)
+}
+
+// countWriter counts the bytes written to it
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,495 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Transforms are changes made to a state file before it is uploaded
+type Transforms struct {
+	// Provider source addresses to replace, old address to new address
+	ReplaceProviders map[string]string
+	// Module or resource addresses to move, old address to new address
+	Moves map[string]string
+	// Module or resource addresses to remove
+	Removals []string
+	// Give the state a new lineage
+	RegenerateLineage bool
+}
+
+// Change is a single before and after change made by a transform
+type Change struct {
+	Transform string
+	Before    string
+	After     string
+}
+
+// Result is a transformed state file
+type Result struct {
+	State   []byte
+	Parsed  *State
+	MD5     string
+	Changes []Change
+}
+
+// TransformsFromConfig reads the state transforms from the config file
+func TransformsFromConfig() (Transforms, error) {
+	t := Transforms{
+		ReplaceProviders:  map[string]string{},
+		Moves:             map[string]string{},
+		Removals:          viper.GetStringSlice("state-removals"),
+		RegenerateLineage: viper.GetBool("state-regenerate-lineage"),
+	}
+
+	for key, m := range map[string]map[string]string{"state-replace-providers": t.ReplaceProviders, "state-moves": t.Moves} {
+		for _, v := range viper.GetStringSlice(key) {
+			s := strings.SplitN(v, "=", 2)
+			if len(s) != 2 || s[0] == "" || s[1] == "" {
+				return t, fmt.Errorf("invalid %v entry %q, expected old=new", key, v)
+			}
+			m[s[0]] = s[1]
+		}
+	}
+
+	for from, to := range t.Moves {
+		fromAddr, err := parseAddress(from)
+		if err != nil {
+			return t, err
+		}
+		toAddr, err := parseAddress(to)
+		if err != nil {
+			return t, err
+		}
+		if fromAddr.isModule() != toAddr.isModule() {
+			return t, fmt.Errorf("invalid state-moves entry %v=%v, a module can only be moved to a module and a resource to a resource", from, to)
+		}
+	}
+
+	for _, r := range t.Removals {
+		if _, err := parseAddress(r); err != nil {
+			return t, err
+		}
+	}
+
+	return t, nil
+}
+
+// Empty returns true if there are no transforms to apply
+func (t Transforms) Empty() bool {
+	return len(t.ReplaceProviders) == 0 && len(t.Moves) == 0 && len(t.Removals) == 0 && !t.RegenerateLineage
+}
+
+// Diff returns the changes of a transformed state as before and after lines
+func (r *Result) Diff() string {
+	var b strings.Builder
+	for _, c := range r.Changes {
+		fmt.Fprintf(&b, "  %v\n    - %v\n    + %v\n", c.Transform, c.Before, c.After)
+	}
+	return b.String()
+}
+
+// address is a module or resource address
+type address struct {
+	module string
+	mode   string
+	typ    string
+	name   string
+}
+
+func (a address) isModule() bool {
+	return a.typ == ""
+}
+
+func (a address) String() string {
+	if a.isModule() {
+		return a.module
+	}
+
+	r := a.typ + "." + a.name
+	if a.mode == "data" {
+		r = "data." + r
+	}
+	if a.module != "" {
+		r = a.module + "." + r
+	}
+	return r
+}
+
+// Parses a module address such as module.network or module.app["east"] or a resource
+// address such as aws_instance.web, data.aws_ami.ubuntu or module.network.aws_vpc.main
+func parseAddress(s string) (address, error) {
+	a := address{mode: "managed"}
+	rest := s
+
+	modules := []string{}
+	for strings.HasPrefix(rest, "module.") {
+		end := strings.IndexAny(rest[len("module."):], ".[")
+		if end < 0 {
+			end = len(rest)
+		} else {
+			end += len("module.")
+		}
+		if end < len(rest) && rest[end] == '[' {
+			close := strings.Index(rest[end:], "]")
+			if close < 0 {
+				return a, fmt.Errorf("invalid address %q", s)
+			}
+			end += close + 1
+		}
+		modules = append(modules, rest[:end])
+		rest = strings.TrimPrefix(rest[end:], ".")
+	}
+	a.module = strings.Join(modules, ".")
+
+	if rest == "" {
+		if a.module == "" {
+			return a, fmt.Errorf("invalid address %q", s)
+		}
+		return a, nil
+	}
+
+	if strings.HasPrefix(rest, "data.") {
+		a.mode = "data"
+		rest = strings.TrimPrefix(rest, "data.")
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.ContainsAny(rest, "[]") {
+		return a, fmt.Errorf("invalid address %q, only module and resource addresses without instance keys are supported", s)
+	}
+	a.typ, a.name = parts[0], parts[1]
+
+	return a, nil
+}
+
+// Returns true if the module address is the module, or one of its instances or children
+func inModule(module string, parent string) bool {
+	return module == parent || strings.HasPrefix(module, parent+".") || strings.HasPrefix(module, parent+"[")
+}
+
+// Returns true if the resource or dependency address is matched by the given address
+func matchesAddress(resource address, a address) bool {
+	if a.isModule() {
+		return resource.module != "" && inModule(resource.module, a.module)
+	}
+	return resource == a
+}
+
+// Moves a resource address, returning false if the move does not apply to it
+func moveAddress(resource address, from address, to address) (address, bool) {
+	if !matchesAddress(resource, from) {
+		return resource, false
+	}
+	if from.isModule() {
+		resource.module = to.module + strings.TrimPrefix(resource.module, from.module)
+		return resource, true
+	}
+	return to, true
+}
+
+// A move of a module or resource address
+type move struct {
+	from address
+	to   address
+}
+
+// Parses the moves and orders them from the most to the least specific, so a resource move applies
+// before a move of its module and a child module move before a move of its parent module. Only the
+// first matching move applies to an address.
+func parseMoves(moves map[string]string) ([]move, error) {
+	parsed := []move{}
+	for from, to := range moves {
+		fromAddr, err := parseAddress(from)
+		if err != nil {
+			return nil, err
+		}
+		toAddr, err := parseAddress(to)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, move{from: fromAddr, to: toAddr})
+	}
+
+	sort.Slice(parsed, func(i, j int) bool {
+		a, b := parsed[i].from, parsed[j].from
+		if a.isModule() != b.isModule() {
+			return !a.isModule()
+		}
+		if len(a.String()) != len(b.String()) {
+			return len(a.String()) > len(b.String())
+		}
+		return a.String() < b.String()
+	})
+	return parsed, nil
+}
+
+// Moves or removes a dependency address, returning false if it should be removed
+func transformDependency(dep string, moves []move, removals []address) (string, bool) {
+	a, err := parseAddress(dep)
+	if err != nil {
+		return dep, true
+	}
+	for _, m := range moves {
+		if moved, ok := moveAddress(a, m.from, m.to); ok {
+			a = moved
+			break
+		}
+	}
+	for _, r := range removals {
+		if matchesAddress(a, r) {
+			return "", false
+		}
+	}
+	return a.String(), true
+}
+
+// Transform applies transforms to a version 4 state file. The transformed state
+// gets the next serial, so it can be uploaded as a new version after the original.
+// If no transform applies to the state the result has no changes. Moves that give
+// a resource the address of another resource in the state return an error.
+func Transform(data []byte, t Transforms) (*Result, error) {
+	parsed, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Version != 4 {
+		return nil, fmt.Errorf("only version 4 states can be transformed, state is version %d", parsed.Version)
+	}
+
+	s, err := decodeState(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Result{}
+
+	moves, err := parseMoves(t.Moves)
+	if err != nil {
+		return nil, err
+	}
+
+	// Provider replacements are applied in a stable order
+	providers := make([]string, 0, len(t.ReplaceProviders))
+	for from := range t.ReplaceProviders {
+		providers = append(providers, from)
+	}
+	sort.Strings(providers)
+
+	removals := []address{}
+	for _, a := range t.Removals {
+		removal, err := parseAddress(a)
+		if err != nil {
+			return nil, err
+		}
+		removals = append(removals, removal)
+	}
+
+	resources, _ := s["resources"].([]interface{})
+	kept := []interface{}{}
+
+	// The original address of each kept resource by its transformed address
+	addresses := map[address]string{}
+
+	for _, item := range resources {
+		resource, ok := item.(map[string]interface{})
+		if !ok {
+			kept = append(kept, item)
+			continue
+		}
+
+		a := address{}
+		a.module, _ = resource["module"].(string)
+		a.mode, _ = resource["mode"].(string)
+		a.typ, _ = resource["type"].(string)
+		a.name, _ = resource["name"].(string)
+
+		// Replace provider source addresses
+		if provider, ok := resource["provider"].(string); ok {
+			for _, from := range providers {
+				replaced := strings.ReplaceAll(provider, "[\""+from+"\"]", "[\""+t.ReplaceProviders[from]+"\"]")
+				if replaced != provider {
+					r.Changes = append(r.Changes, Change{Transform: "replace provider of " + a.String(), Before: provider, After: replaced})
+					resource["provider"] = replaced
+					break
+				}
+			}
+		}
+
+		// Move the resource
+		original := a.String()
+		for _, m := range moves {
+			if moved, ok := moveAddress(a, m.from, m.to); ok {
+				r.Changes = append(r.Changes, Change{Transform: "move " + m.from.String(), Before: a.String(), After: moved.String()})
+				a = moved
+				if a.module == "" {
+					delete(resource, "module")
+				} else {
+					resource["module"] = a.module
+				}
+				resource["mode"] = a.mode
+				resource["type"] = a.typ
+				resource["name"] = a.name
+				break
+			}
+		}
+
+		// Remove the resource
+		removed := false
+		for _, removal := range removals {
+			if matchesAddress(a, removal) {
+				r.Changes = append(r.Changes, Change{Transform: "remove " + removal.String(), Before: a.String(), After: "(removed)"})
+				removed = true
+				break
+			}
+		}
+		if removed {
+			continue
+		}
+
+		if other, ok := addresses[a]; ok {
+			return nil, fmt.Errorf("state-moves give both %v and %v the address %v, a move target can not already exist in the state", other, original, a)
+		}
+		addresses[a] = original
+
+		// Update the dependencies of each instance
+		instances, _ := resource["instances"].([]interface{})
+		for _, i := range instances {
+			instance, ok := i.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			deps, ok := instance["dependencies"].([]interface{})
+			if !ok {
+				continue
+			}
+			newDeps := []interface{}{}
+			for _, dep := range deps {
+				depAddress, ok := dep.(string)
+				if !ok {
+					newDeps = append(newDeps, dep)
+					continue
+				}
+				if newDep, keep := transformDependency(depAddress, moves, removals); keep {
+					newDeps = append(newDeps, newDep)
+				}
+			}
+			instance["dependencies"] = newDeps
+		}
+
+		kept = append(kept, resource)
+	}
+
+	if resources != nil {
+		s["resources"] = kept
+	}
+
+	// Nothing to change, the state is returned as it is
+	if len(r.Changes) == 0 && !t.RegenerateLineage {
+		r.State, r.Parsed, r.MD5 = data, parsed, MD5(data)
+		return r, nil
+	}
+
+	if t.RegenerateLineage {
+		lineage := uuid.NewString()
+		r.Changes = append(r.Changes, Change{Transform: "regenerate lineage", Before: parsed.Lineage, After: lineage})
+		s["lineage"] = lineage
+	}
+
+	serial := parsed.Serial + 1
+	r.Changes = append(r.Changes, Change{Transform: "serial", Before: fmt.Sprint(parsed.Serial), After: fmt.Sprint(serial)})
+	s["serial"] = serial
+
+	// Keep the order of the changes stable between runs
+	sort.SliceStable(r.Changes, func(i, j int) bool {
+		return changeOrder(r.Changes[i]) < changeOrder(r.Changes[j])
+	})
+
+	r.State, err = encodeState(s)
+	if err != nil {
+		return nil, err
+	}
+	r.MD5 = MD5(r.State)
+	r.Changes = append(r.Changes, Change{Transform: "md5", Before: MD5(data), After: r.MD5})
+
+	r.Parsed, err = Parse(r.State)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Orders changes by the type of transform
+func changeOrder(c Change) int {
+	for i, prefix := range []string{"replace provider", "move", "remove", "regenerate lineage", "serial"} {
+		if strings.HasPrefix(c.Transform, prefix) {
+			return i
+		}
+	}
+	return 0
+}
+
+// WithSerial returns a state file with a new serial, so an older state can be
+// uploaded as a new version on top of a newer state
+func WithSerial(data []byte, serial int64) (*Result, error) {
+	parsed, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := decodeState(data)
+	if err != nil {
+		return nil, err
+	}
+	s["serial"] = serial
+
+	r := &Result{
+		Changes: []Change{{Transform: "serial", Before: fmt.Sprint(parsed.Serial), After: fmt.Sprint(serial)}},
+	}
+
+	r.State, err = encodeState(s)
+	if err != nil {
+		return nil, err
+	}
+	r.MD5 = MD5(r.State)
+	r.Changes = append(r.Changes, Change{Transform: "md5", Before: MD5(data), After: r.MD5})
+
+	r.Parsed, err = Parse(r.State)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Decodes a state file keeping numbers as they are written
+func decodeState(data []byte) (map[string]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	s := map[string]interface{}{}
+	if err := d.Decode(&s); err != nil {
+		return nil, fmt.Errorf("unable to parse state: %v", err)
+	}
+	return s, nil
+}
+
+// Encodes a state file the way Terraform writes it, indented and without escaping HTML
+func encodeState(s map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(s); err != nil {
+		return nil, fmt.Errorf("unable to write state: %v", err)
+	}
+	return b.Bytes(), nil
+}
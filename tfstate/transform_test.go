@@ -0,0 +1,334 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tfstate
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testState = `{
+  "version": 4,
+  "terraform_version": "1.6.0",
+  "serial": 7,
+  "lineage": "11111111-2222-3333-4444-555555555555",
+  "outputs": {
+    "id": {"value": "i-123", "type": "string"}
+  },
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"schema_version": 1, "attributes": {"id": "i-123"}, "dependencies": ["module.network.aws_vpc.main"]}
+      ]
+    },
+    {
+      "module": "module.network",
+      "mode": "managed",
+      "type": "aws_vpc",
+      "name": "main",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"schema_version": 0, "attributes": {"id": "vpc-1"}}
+      ]
+    },
+    {
+      "module": "module.network",
+      "mode": "managed",
+      "type": "aws_subnet",
+      "name": "a",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"schema_version": 0, "attributes": {"id": "subnet-1"}, "dependencies": ["module.network.aws_vpc.main"]}
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"schema_version": 0, "attributes": {"id": "ami-1"}}
+      ]
+    }
+  ]
+}
+`
+
+// The resources of a transformed state
+type testResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Instances []struct {
+		Dependencies []string `json:"dependencies"`
+	} `json:"instances"`
+}
+
+// Returns the resources of a state by address
+func testResources(t *testing.T, data []byte) map[string]testResource {
+	t.Helper()
+
+	s := struct {
+		Resources []testResource `json:"resources"`
+	}{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("unable to parse transformed state: %v", err)
+	}
+
+	resources := map[string]testResource{}
+	for _, r := range s.Resources {
+		a := address{module: r.Module, mode: r.Mode, typ: r.Type, name: r.Name}
+		resources[a.String()] = r
+	}
+	return resources
+}
+
+// Returns the sorted keys of a map
+func testKeys(m map[string]testResource) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestTransform(t *testing.T) {
+	cases := []struct {
+		name       string
+		transforms Transforms
+		addresses  []string
+		// Expected dependencies by resource address
+		dependencies map[string][]string
+		// Expected provider by resource address
+		providers map[string]string
+		err       string
+	}{
+		{
+			name:       "move resource",
+			transforms: Transforms{Moves: map[string]string{"aws_instance.web": "aws_instance.app"}},
+			addresses:  []string{"aws_instance.app", "data.aws_ami.ubuntu", "module.network.aws_subnet.a", "module.network.aws_vpc.main"},
+		},
+		{
+			name:       "move module updates dependencies",
+			transforms: Transforms{Moves: map[string]string{"module.network": "module.vpc"}},
+			addresses:  []string{"aws_instance.web", "data.aws_ami.ubuntu", "module.vpc.aws_subnet.a", "module.vpc.aws_vpc.main"},
+			dependencies: map[string][]string{
+				"aws_instance.web":        {"module.vpc.aws_vpc.main"},
+				"module.vpc.aws_subnet.a": {"module.vpc.aws_vpc.main"},
+			},
+		},
+		{
+			name: "overlapping moves apply the most specific move",
+			transforms: Transforms{Moves: map[string]string{
+				"module.network":              "module.vpc",
+				"module.network.aws_vpc.main": "aws_vpc.main",
+			}},
+			addresses: []string{"aws_instance.web", "aws_vpc.main", "data.aws_ami.ubuntu", "module.vpc.aws_subnet.a"},
+			dependencies: map[string][]string{
+				"aws_instance.web":        {"aws_vpc.main"},
+				"module.vpc.aws_subnet.a": {"aws_vpc.main"},
+			},
+		},
+		{
+			name:       "remove module removes dependencies",
+			transforms: Transforms{Removals: []string{"module.network"}},
+			addresses:  []string{"aws_instance.web", "data.aws_ami.ubuntu"},
+			dependencies: map[string][]string{
+				"aws_instance.web": {},
+			},
+		},
+		{
+			name:       "remove data source",
+			transforms: Transforms{Removals: []string{"data.aws_ami.ubuntu"}},
+			addresses:  []string{"aws_instance.web", "module.network.aws_subnet.a", "module.network.aws_vpc.main"},
+		},
+		{
+			name:       "replace provider",
+			transforms: Transforms{ReplaceProviders: map[string]string{"registry.terraform.io/hashicorp/aws": "registry.example.com/acme/aws"}},
+			addresses:  []string{"aws_instance.web", "data.aws_ami.ubuntu", "module.network.aws_subnet.a", "module.network.aws_vpc.main"},
+			providers: map[string]string{
+				"aws_instance.web":            "provider[\"registry.example.com/acme/aws\"]",
+				"module.network.aws_vpc.main": "provider[\"registry.example.com/acme/aws\"]",
+			},
+		},
+		{
+			name:       "move to an existing resource",
+			transforms: Transforms{Moves: map[string]string{"module.network.aws_vpc.main": "aws_instance.web"}},
+			err:        "a move target can not already exist in the state",
+		},
+		{
+			name: "moves to the same resource",
+			transforms: Transforms{Moves: map[string]string{
+				"module.network.aws_vpc.main": "aws_vpc.main",
+				"module.network.aws_subnet.a": "aws_vpc.main",
+			}},
+			err: "a move target can not already exist in the state",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Transform([]byte(testState), tc.transforms)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if r.Parsed.Serial != 8 {
+				t.Errorf("expected serial 8, got %d", r.Parsed.Serial)
+			}
+			if r.Parsed.Lineage != "11111111-2222-3333-4444-555555555555" {
+				t.Errorf("expected the lineage to be kept, got %v", r.Parsed.Lineage)
+			}
+			if r.MD5 != MD5(r.State) {
+				t.Errorf("expected MD5 %v, got %v", MD5(r.State), r.MD5)
+			}
+
+			resources := testResources(t, r.State)
+			if got := strings.Join(testKeys(resources), ","); got != strings.Join(tc.addresses, ",") {
+				t.Errorf("expected resources %v, got %v", tc.addresses, got)
+			}
+
+			for a, expected := range tc.dependencies {
+				got := resources[a].Instances[0].Dependencies
+				if strings.Join(got, ",") != strings.Join(expected, ",") {
+					t.Errorf("expected dependencies %v of %v, got %v", expected, a, got)
+				}
+			}
+
+			for a, expected := range tc.providers {
+				if got := resources[a].Provider; got != expected {
+					t.Errorf("expected provider %v of %v, got %v", expected, a, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformStableOrder(t *testing.T) {
+	transforms := Transforms{Moves: map[string]string{
+		"module.network":              "module.vpc",
+		"module.network.aws_vpc.main": "aws_vpc.main",
+		"aws_instance.web":            "aws_instance.app",
+	}}
+
+	first, err := Transform([]byte(testState), transforms)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		r, err := Transform([]byte(testState), transforms)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.MD5 != first.MD5 || r.Diff() != first.Diff() {
+			t.Fatalf("expected the same transformed state on every run")
+		}
+	}
+}
+
+func TestTransformNoChanges(t *testing.T) {
+	r, err := Transform([]byte(testState), Transforms{Moves: map[string]string{"module.other": "module.new"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", r.Changes)
+	}
+	if string(r.State) != testState || r.Parsed.Serial != 7 {
+		t.Errorf("expected the state to be returned as it is")
+	}
+}
+
+func TestTransformRegenerateLineage(t *testing.T) {
+	r, err := Transform([]byte(testState), Transforms{RegenerateLineage: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Parsed.Lineage == "" || r.Parsed.Lineage == "11111111-2222-3333-4444-555555555555" {
+		t.Errorf("expected a new lineage, got %v", r.Parsed.Lineage)
+	}
+	if r.Parsed.Serial != 8 {
+		t.Errorf("expected serial 8, got %d", r.Parsed.Serial)
+	}
+}
+
+func TestTransformVersion3(t *testing.T) {
+	_, err := Transform([]byte(`{"version": 3, "serial": 1, "lineage": "x", "modules": []}`), Transforms{Removals: []string{"aws_instance.web"}})
+	if err == nil {
+		t.Fatalf("expected an error for a version 3 state")
+	}
+}
+
+func TestWithSerial(t *testing.T) {
+	r, err := WithSerial([]byte(testState), 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Parsed.Serial != 12 {
+		t.Errorf("expected serial 12, got %d", r.Parsed.Serial)
+	}
+	if r.Parsed.Lineage != "11111111-2222-3333-4444-555555555555" {
+		t.Errorf("expected the lineage to be kept, got %v", r.Parsed.Lineage)
+	}
+	if r.MD5 != MD5(r.State) || r.MD5 == MD5([]byte(testState)) {
+		t.Errorf("expected a new MD5 of the state, got %v", r.MD5)
+	}
+	if got := strings.Join(testKeys(testResources(t, r.State)), ","); got != "aws_instance.web,data.aws_ami.ubuntu,module.network.aws_subnet.a,module.network.aws_vpc.main" {
+		t.Errorf("expected the resources to be kept, got %v", got)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected address
+		err      bool
+	}{
+		{input: "aws_instance.web", expected: address{mode: "managed", typ: "aws_instance", name: "web"}},
+		{input: "data.aws_ami.ubuntu", expected: address{mode: "data", typ: "aws_ami", name: "ubuntu"}},
+		{input: "module.network", expected: address{mode: "managed", module: "module.network"}},
+		{input: "module.app[\"east\"]", expected: address{mode: "managed", module: "module.app[\"east\"]"}},
+		{input: "module.a.module.b.aws_vpc.main", expected: address{mode: "managed", module: "module.a.module.b", typ: "aws_vpc", name: "main"}},
+		{input: "aws_instance.web[0]", err: true},
+		{input: "aws_instance", err: true},
+		{input: "", err: true},
+		{input: "module.app[\"east\"", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			a, err := parseAddress(tc.input)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", a)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if a != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, a)
+			}
+			if a.String() != tc.input {
+				t.Errorf("expected %v, got %v", tc.input, a.String())
+			}
+		})
+	}
+}